@@ -0,0 +1,70 @@
+package hw05parallelexecution
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ErrErrorRateExceeded = errors.New("errors rate exceeded")
+	ErrInvalidErrorRate  = errors.New("error rate threshold should be in [0, 1] and window >= 0")
+)
+
+// ErrorRate останавливает запуск, когда доля упавших задач среди последних Window
+// завершенных превышает Threshold. Пока окно не заполнено, запуск не останавливается.
+type ErrorRate struct {
+	Threshold float64
+	Window    int
+}
+
+func (r ErrorRate) validate() error {
+	if r.Window < 0 || r.Threshold < 0 || r.Threshold > 1 {
+		return ErrInvalidErrorRate
+	}
+	return nil
+}
+
+type errorWindow struct {
+	mu        sync.Mutex
+	threshold float64
+	results   []bool
+	next      int
+	count     int
+	failures  int
+	tripped   bool
+}
+
+func newErrorWindow(rate ErrorRate) *errorWindow {
+	return &errorWindow{
+		threshold: rate.Threshold,
+		results:   make([]bool, rate.Window),
+	}
+}
+
+func (w *errorWindow) record(failed bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.count == len(w.results) {
+		// вытесняем самый старый результат
+		if w.results[w.next] {
+			w.failures--
+		}
+	} else {
+		w.count++
+	}
+	w.results[w.next] = failed
+	w.next = (w.next + 1) % len(w.results)
+	if failed {
+		w.failures++
+	}
+	if w.count == len(w.results) && float64(w.failures)/float64(w.count) > w.threshold {
+		// после срабатывания запуск остановлен окончательно
+		w.tripped = true
+	}
+}
+
+func (w *errorWindow) exceeded() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tripped
+}
@@ -0,0 +1,107 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestErrorWindow(t *testing.T) {
+	w := newErrorWindow(ErrorRate{Threshold: 0.5, Window: 4})
+
+	// окно не заполнено - не срабатывает даже при 100% ошибок
+	w.record(true)
+	w.record(true)
+	w.record(true)
+	require.False(t, w.exceeded())
+
+	w = newErrorWindow(ErrorRate{Threshold: 0.5, Window: 4})
+	for _, failed := range []bool{true, true, false, false, false, true, false} {
+		w.record(failed)
+		require.False(t, w.exceeded())
+	}
+	w.record(true)
+	require.False(t, w.exceeded())
+	w.record(true)
+	require.True(t, w.exceeded())
+
+	// срабатывание необратимо
+	w.record(false)
+	w.record(false)
+	require.True(t, w.exceeded())
+}
+
+func TestRunErrorRate(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	errTask := errors.New("task error")
+	t.Run("rate below threshold", func(t *testing.T) {
+		tasks := make([]ContextTask, 0, 100)
+		for i := 0; i < 100; i++ {
+			i := i
+			tasks = append(tasks, func(ctx context.Context) error {
+				if i%10 == 0 {
+					return errTask
+				}
+				return nil
+			})
+		}
+		res, err := RunContext(context.Background(), tasks, Options{
+			Workers:   1,
+			ErrorRate: ErrorRate{Threshold: 0.2, Window: 10},
+		})
+		require.True(t, errors.Is(err, errTask), "actual err - %v", err)
+		require.False(t, errors.Is(err, ErrErrorRateExceeded), "actual err - %v", err)
+		require.Len(t, res.Failed(), 10)
+	})
+
+	t.Run("rate above threshold", func(t *testing.T) {
+		tasks := make([]ContextTask, 0, 100)
+		for i := 0; i < 100; i++ {
+			i := i
+			tasks = append(tasks, func(ctx context.Context) error {
+				if i >= 50 && i%2 == 0 {
+					return errTask
+				}
+				return nil
+			})
+		}
+		res, err := RunContext(context.Background(), tasks, Options{
+			Workers:   1,
+			ErrorRate: ErrorRate{Threshold: 0.3, Window: 10},
+		})
+		require.True(t, errors.Is(err, ErrErrorRateExceeded), "actual err - %v", err)
+		require.True(t, res.Tasks[len(tasks)-1].Skipped)
+		require.False(t, res.Tasks[50].Skipped)
+	})
+
+	t.Run("invalid rate", func(t *testing.T) {
+		_, err := RunContext(context.Background(), nil, Options{
+			Workers:   1,
+			ErrorRate: ErrorRate{Threshold: 1.5, Window: 10},
+		})
+		require.True(t, errors.Is(err, ErrInvalidErrorRate), "actual err - %v", err)
+	})
+}
+
+func TestRunIgnoreErrors(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	errTask := errors.New("task error")
+	tasks := make([]ContextTask, 0, 50)
+	for i := 0; i < 50; i++ {
+		tasks = append(tasks, func(ctx context.Context) error { return errTask })
+	}
+
+	res, err := RunContext(context.Background(), tasks, Options{
+		Workers:      5,
+		MaxErrors:    0,
+		IgnoreErrors: true,
+	})
+	require.True(t, errors.Is(err, errTask), "actual err - %v", err)
+	require.False(t, errors.Is(err, ErrErrorsLimitExceeded), "actual err - %v", err)
+	require.Len(t, res.Failed(), len(tasks))
+}
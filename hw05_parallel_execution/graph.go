@@ -0,0 +1,228 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrDuplicateTask     = errors.New("duplicate task")
+	ErrUnknownDependency = errors.New("unknown dependency")
+	ErrDependencyCycle   = errors.New("dependency cycle")
+	ErrDependencyFailed  = errors.New("dependency failed")
+)
+
+// Graph - набор именованных задач с зависимостями между ними.
+type Graph struct {
+	nodes map[string]*graphNode
+	// порядок добавления, чтобы готовые задачи запускались предсказуемо
+	order []*graphNode
+}
+
+type graphNode struct {
+	name       string
+	task       ContextTask
+	deps       []string
+	dependants []*graphNode
+}
+
+func NewGraph() *Graph {
+	return &Graph{
+		nodes: make(map[string]*graphNode),
+	}
+}
+
+// Add регистрирует задачу. Зависимости могут быть добавлены позже, проверяются они при запуске.
+func (g *Graph) Add(name string, task ContextTask, deps ...string) error {
+	if _, ok := g.nodes[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateTask, name)
+	}
+	node := &graphNode{
+		name: name,
+		task: task,
+		deps: deps,
+	}
+	g.nodes[name] = node
+	g.order = append(g.order, node)
+	return nil
+}
+
+// Validate проверяет, что все зависимости существуют и в графе нет циклов.
+func (g *Graph) Validate() error {
+	for _, node := range g.order {
+		for _, dep := range node.deps {
+			if _, ok := g.nodes[dep]; !ok {
+				return fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, node.name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(g.nodes))
+	path := make([]string, 0, len(g.nodes))
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			// путь от первого вхождения name до текущей вершины и есть цикл
+			for i, n := range path {
+				if n == name {
+					cycle := append(path[i:len(path):len(path)], name)
+					return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> "))
+				}
+			}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range g.nodes[name].deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, node := range g.order {
+		if err := visit(node.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run выполняет задачи графа в opts.Workers горутинах, запуская задачу только после успешного
+// завершения всех ее зависимостей. Если задача упала, зависящие от нее задачи пропускаются
+// с ошибкой ErrDependencyFailed, а независимые ветки продолжают выполняться.
+func (g *Graph) Run(ctx context.Context, opts Options) (map[string]TaskResult, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	executor, err := NewTaskExecutor(opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range g.order {
+		node.dependants = node.dependants[:0]
+	}
+	for _, node := range g.order {
+		for _, dep := range node.deps {
+			g.nodes[dep].dependants = append(g.nodes[dep].dependants, node)
+		}
+	}
+
+	src := newGraphSource(g)
+	cause := executor.run(ctx, src, src.finish)
+
+	failed := make([]TaskResult, 0)
+	for _, node := range g.order {
+		res, ok := src.results[node.name]
+		if !ok {
+			src.results[node.name] = TaskResult{Name: node.name, Skipped: true}
+			continue
+		}
+		if res.Err != nil && !res.Skipped {
+			failed = append(failed, res)
+		}
+	}
+	return src.results, Result{Tasks: failed}.err(cause)
+}
+
+// graphSource выдает задачи графа по мере готовности их зависимостей.
+type graphSource struct {
+	mu      sync.Mutex
+	ready   []*graphNode
+	pending map[string]int
+	// remaining - задачи, которые еще не завершились и не были пропущены
+	remaining  int
+	dispatched []*graphNode
+	results    map[string]TaskResult
+	notify     chan struct{}
+}
+
+func newGraphSource(g *Graph) *graphSource {
+	s := &graphSource{
+		pending:   make(map[string]int, len(g.order)),
+		remaining: len(g.order),
+		results:   make(map[string]TaskResult, len(g.order)),
+		notify:    make(chan struct{}, 1),
+	}
+	for _, node := range g.order {
+		s.pending[node.name] = len(node.deps)
+		if len(node.deps) == 0 {
+			s.ready = append(s.ready, node)
+		}
+	}
+	return s
+}
+
+func (s *graphSource) Next(ctx context.Context) (ContextTask, bool) {
+	for {
+		s.mu.Lock()
+		if len(s.ready) > 0 {
+			node := s.ready[0]
+			s.ready = s.ready[1:]
+			s.dispatched = append(s.dispatched, node)
+			s.mu.Unlock()
+			return node.task, true
+		}
+		remaining := s.remaining
+		s.mu.Unlock()
+		if remaining == 0 {
+			return nil, false
+		}
+		// ждем завершения запущенных задач
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-s.notify:
+		}
+	}
+}
+
+func (s *graphSource) finish(res TaskResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	node := s.dispatched[res.Index]
+	res.Name = node.name
+	s.results[node.name] = res
+	s.remaining--
+	if res.Err != nil || res.Skipped {
+		s.skipDependants(node)
+	} else {
+		for _, dependant := range node.dependants {
+			s.pending[dependant.name]--
+			if s.pending[dependant.name] == 0 {
+				s.ready = append(s.ready, dependant)
+			}
+		}
+	}
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *graphSource) skipDependants(node *graphNode) {
+	for _, dependant := range node.dependants {
+		if _, ok := s.results[dependant.name]; ok {
+			continue
+		}
+		s.results[dependant.name] = TaskResult{
+			Name:    dependant.name,
+			Err:     fmt.Errorf("%w: %s", ErrDependencyFailed, node.name),
+			Skipped: true,
+		}
+		s.remaining--
+		s.skipDependants(dependant)
+	}
+}
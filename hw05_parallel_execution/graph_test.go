@@ -0,0 +1,162 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+// recorder запоминает порядок завершения задач графа.
+type recorder struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (r *recorder) task(name string, err error) ContextTask {
+	return func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.order = append(r.order, name)
+		return err
+	}
+}
+
+func (r *recorder) index(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, n := range r.order {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestGraphValidate(t *testing.T) {
+	noop := func(ctx context.Context) error { return nil }
+
+	t.Run("duplicate", func(t *testing.T) {
+		g := NewGraph()
+		require.NoError(t, g.Add("a", noop))
+		err := g.Add("a", noop)
+		require.True(t, errors.Is(err, ErrDuplicateTask), "actual err - %v", err)
+	})
+
+	t.Run("unknown dependency", func(t *testing.T) {
+		g := NewGraph()
+		require.NoError(t, g.Add("a", noop, "b"))
+		err := g.Validate()
+		require.True(t, errors.Is(err, ErrUnknownDependency), "actual err - %v", err)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		g := NewGraph()
+		require.NoError(t, g.Add("a", noop))
+		require.NoError(t, g.Add("b", noop, "a", "d"))
+		require.NoError(t, g.Add("c", noop, "b"))
+		require.NoError(t, g.Add("d", noop, "c"))
+		err := g.Validate()
+		require.True(t, errors.Is(err, ErrDependencyCycle), "actual err - %v", err)
+		require.EqualError(t, err, "dependency cycle: b -> d -> c -> b")
+
+		_, err = g.Run(context.Background(), Options{Workers: 1, MaxErrors: 1})
+		require.True(t, errors.Is(err, ErrDependencyCycle), "actual err - %v", err)
+	})
+
+	t.Run("self dependency", func(t *testing.T) {
+		g := NewGraph()
+		require.NoError(t, g.Add("a", noop, "a"))
+		err := g.Validate()
+		require.True(t, errors.Is(err, ErrDependencyCycle), "actual err - %v", err)
+	})
+}
+
+func TestGraphRun(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("dependencies order", func(t *testing.T) {
+		rec := &recorder{}
+		g := NewGraph()
+		require.NoError(t, g.Add("link", rec.task("link", nil), "compile-a", "compile-b"))
+		require.NoError(t, g.Add("compile-a", rec.task("compile-a", nil), "generate"))
+		require.NoError(t, g.Add("compile-b", rec.task("compile-b", nil)))
+		require.NoError(t, g.Add("generate", rec.task("generate", nil)))
+		require.NoError(t, g.Add("test", rec.task("test", nil), "link"))
+
+		results, err := g.Run(context.Background(), Options{Workers: 3, MaxErrors: 1})
+		require.NoError(t, err)
+		require.Len(t, results, 5)
+		for name, res := range results {
+			require.Equal(t, name, res.Name)
+			require.False(t, res.Skipped)
+		}
+		require.Less(t, rec.index("generate"), rec.index("compile-a"))
+		require.Less(t, rec.index("compile-a"), rec.index("link"))
+		require.Less(t, rec.index("compile-b"), rec.index("link"))
+		require.Less(t, rec.index("link"), rec.index("test"))
+	})
+
+	t.Run("independent tasks run in parallel", func(t *testing.T) {
+		workersCount := 4
+		var running int32
+		g := NewGraph()
+		for _, name := range []string{"a", "b", "c", "d"} {
+			require.NoError(t, g.Add(name, func(ctx context.Context) error {
+				atomic.AddInt32(&running, 1)
+				// задача ждет, пока не запустятся все остальные
+				for atomic.LoadInt32(&running) < int32(workersCount) {
+					time.Sleep(time.Millisecond)
+				}
+				return nil
+			}))
+		}
+		_, err := g.Run(context.Background(), Options{Workers: workersCount, MaxErrors: 1})
+		require.NoError(t, err)
+	})
+
+	t.Run("failed task skips dependants", func(t *testing.T) {
+		errTask := errors.New("task error")
+		rec := &recorder{}
+		g := NewGraph()
+		require.NoError(t, g.Add("a", rec.task("a", errTask)))
+		require.NoError(t, g.Add("b", rec.task("b", nil), "a"))
+		require.NoError(t, g.Add("c", rec.task("c", nil), "b"))
+		require.NoError(t, g.Add("x", rec.task("x", nil)))
+		require.NoError(t, g.Add("y", rec.task("y", nil), "x"))
+
+		results, err := g.Run(context.Background(), Options{Workers: 2, MaxErrors: 2})
+		require.True(t, errors.Is(err, errTask), "actual err - %v", err)
+		require.False(t, errors.Is(err, ErrErrorsLimitExceeded), "actual err - %v", err)
+
+		require.True(t, errors.Is(results["a"].Err, errTask))
+		for _, name := range []string{"b", "c"} {
+			require.True(t, results[name].Skipped)
+			require.True(t, errors.Is(results[name].Err, ErrDependencyFailed))
+			require.Equal(t, -1, rec.index(name))
+		}
+		require.NoError(t, results["y"].Err)
+		require.NotEqual(t, -1, rec.index("y"))
+	})
+
+	t.Run("errors limit", func(t *testing.T) {
+		errTask := errors.New("task error")
+		rec := &recorder{}
+		g := NewGraph()
+		require.NoError(t, g.Add("a", rec.task("a", errTask)))
+		require.NoError(t, g.Add("b", rec.task("b", nil), "a"))
+		require.NoError(t, g.Add("c", rec.task("c", nil)))
+
+		results, err := g.Run(context.Background(), Options{Workers: 1, MaxErrors: 1})
+		require.True(t, errors.Is(err, ErrErrorsLimitExceeded), "actual err - %v", err)
+		require.True(t, errors.Is(err, errTask), "actual err - %v", err)
+		require.True(t, results["c"].Skipped)
+		require.NoError(t, results["c"].Err)
+		require.Equal(t, -1, rec.index("c"))
+	})
+}
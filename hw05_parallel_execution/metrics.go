@@ -0,0 +1,210 @@
+package hw05parallelexecution
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultLatencyWindow = 1024
+
+var _ Observer = (*Metrics)(nil)
+
+// Metrics - Observer, который считает задачи и хранит длительности последних задач
+// для расчета перцентилей.
+type Metrics struct {
+	mu        sync.Mutex
+	queued    int64
+	running   int64
+	done      int64
+	failed    int64
+	retries   int64
+	aborts    int64
+	sum       time.Duration
+	latencies []time.Duration
+	next      int
+	count     int
+}
+
+type MetricsSnapshot struct {
+	Queued  int64
+	Running int64
+	Done    int64
+	Failed  int64
+	Retries int64
+	Aborts  int64
+	P50     time.Duration
+	P90     time.Duration
+	P99     time.Duration
+}
+
+// NewMetrics создает сборщик, считающий перцентили по window последним задачам.
+func NewMetrics(window int) *Metrics {
+	if window <= 0 {
+		window = defaultLatencyWindow
+	}
+	return &Metrics{
+		latencies: make([]time.Duration, window),
+	}
+}
+
+func (m *Metrics) OnQueue(int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queued++
+}
+
+func (m *Metrics) OnStart(int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.queued > 0 {
+		m.queued--
+	}
+	m.running++
+}
+
+func (m *Metrics) OnSkip(int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.queued > 0 {
+		m.queued--
+	}
+}
+
+func (m *Metrics) OnRetry(int, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries++
+}
+
+func (m *Metrics) OnFinish(_ int, err error, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running--
+	m.done++
+	if err != nil {
+		m.failed++
+	}
+	m.sum += duration
+	m.latencies[m.next] = duration
+	m.next = (m.next + 1) % len(m.latencies)
+	if m.count < len(m.latencies) {
+		m.count++
+	}
+}
+
+func (m *Metrics) OnAbort(error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.aborts++
+}
+
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sorted := m.sortedLatencies()
+	return MetricsSnapshot{
+		Queued:  m.queued,
+		Running: m.running,
+		Done:    m.done,
+		Failed:  m.failed,
+		Retries: m.retries,
+		Aborts:  m.aborts,
+		P50:     quantile(sorted, 0.5),
+		P90:     quantile(sorted, 0.9),
+		P99:     quantile(sorted, 0.99),
+	}
+}
+
+// Quantile возвращает q-перцентиль (0 <= q <= 1) длительности последних задач.
+func (m *Metrics) Quantile(q float64) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return quantile(m.sortedLatencies(), q)
+}
+
+func (m *Metrics) sortedLatencies() []time.Duration {
+	sorted := make([]time.Duration, m.count)
+	copy(sorted, m.latencies[:m.count])
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+func quantile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(q*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+// WritePrometheus пишет метрики в текстовом формате Prometheus, namespace добавляется
+// префиксом к именам метрик.
+func (m *Metrics) WritePrometheus(w io.Writer, namespace string) error {
+	m.mu.Lock()
+	sorted := m.sortedLatencies()
+	s := MetricsSnapshot{
+		Queued:  m.queued,
+		Running: m.running,
+		Done:    m.done,
+		Failed:  m.failed,
+		Retries: m.retries,
+		Aborts:  m.aborts,
+	}
+	sum := m.sum
+	m.mu.Unlock()
+
+	prefix := ""
+	if namespace != "" {
+		prefix = namespace + "_"
+	}
+	metrics := []struct {
+		name, kind, help string
+		value            int64
+	}{
+		{"tasks_queued", "gauge", "Tasks waiting for a worker.", s.Queued},
+		{"tasks_running", "gauge", "Tasks being executed.", s.Running},
+		{"tasks_done_total", "counter", "Finished tasks.", s.Done},
+		{"tasks_failed_total", "counter", "Tasks finished with an error.", s.Failed},
+		{"tasks_retries_total", "counter", "Task retries.", s.Retries},
+		{"tasks_aborts_total", "counter", "Aborted runs.", s.Aborts},
+	}
+	for _, metric := range metrics {
+		name := prefix + metric.name
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n",
+			name, metric.help, name, metric.kind, name, metric.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	name := prefix + "task_duration_seconds"
+	_, err := fmt.Fprintf(w, "# HELP %s Task duration over the latest tasks.\n# TYPE %s summary\n", name, name)
+	if err != nil {
+		return err
+	}
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		_, err = fmt.Fprintf(w, "%s{quantile=\"%s\"} %s\n", name,
+			strconv.FormatFloat(q, 'g', -1, 64), seconds(quantile(sorted, q)))
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", name, seconds(sum), name, s.Done)
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
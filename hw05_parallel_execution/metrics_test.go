@@ -0,0 +1,254 @@
+package hw05parallelexecution
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+type abortObserver struct {
+	nopObserver
+	mu     sync.Mutex
+	aborts []error
+}
+
+func (o *abortObserver) OnAbort(err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.aborts = append(o.aborts, err)
+}
+
+func TestMetricsQuantiles(t *testing.T) {
+	m := NewMetrics(100)
+	for i := 1; i <= 200; i++ {
+		m.OnStart(i)
+		m.OnFinish(i, nil, time.Duration(i)*time.Millisecond)
+	}
+	// учитываются только 100 последних задач: 101..200 мс
+	require.Equal(t, 150*time.Millisecond, m.Quantile(0.5))
+	require.Equal(t, 190*time.Millisecond, m.Quantile(0.9))
+	require.Equal(t, 199*time.Millisecond, m.Quantile(0.99))
+	require.Equal(t, 200*time.Millisecond, m.Quantile(1))
+	require.Equal(t, 101*time.Millisecond, m.Quantile(0))
+
+	s := m.Snapshot()
+	require.Equal(t, int64(200), s.Done)
+	require.Equal(t, int64(0), s.Running)
+	require.Equal(t, 150*time.Millisecond, s.P50)
+
+	require.Equal(t, time.Duration(0), NewMetrics(0).Quantile(0.5))
+}
+
+func TestMetricsObserver(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	errTask := errors.New("task error")
+	var attempts int32
+	tasks := []ContextTask{
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error {
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				return errTask
+			}
+			return nil
+		},
+		func(ctx context.Context) error { return errTask },
+		func(ctx context.Context) error { return errTask },
+		func(ctx context.Context) error { return nil },
+	}
+
+	m := NewMetrics(0)
+	_, err := RunContext(context.Background(), tasks, Options{
+		Workers:   1,
+		MaxErrors: 1,
+		Observer:  m,
+		Retry: RetryPolicy{
+			MaxAttempts: 2,
+			Retryable: func(err error) bool {
+				return atomic.LoadInt32(&attempts) == 1
+			},
+		},
+	})
+	require.True(t, errors.Is(err, ErrErrorsLimitExceeded), "actual err - %v", err)
+
+	s := m.Snapshot()
+	require.Equal(t, int64(3), s.Done)
+	require.Equal(t, int64(1), s.Failed)
+	require.Equal(t, int64(1), s.Retries)
+	require.Equal(t, int64(1), s.Aborts)
+	require.Equal(t, int64(0), s.Running)
+	require.Equal(t, int64(0), s.Queued)
+}
+
+func TestObserverAbort(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	o := &abortObserver{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := RunContext(ctx, []ContextTask{func(ctx context.Context) error { return nil }}, Options{
+		Workers:   1,
+		MaxErrors: 1,
+		Observer:  o,
+	})
+	require.True(t, errors.Is(err, context.Canceled), "actual err - %v", err)
+	require.Len(t, o.aborts, 1)
+	require.True(t, errors.Is(o.aborts[0], context.Canceled))
+}
+
+func TestMetricsPool(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	m := NewMetrics(0)
+	pool, err := NewPool(Options{Workers: 1, Observer: m})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	pool.Submit(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	pool.Submit(func(ctx context.Context) error { return nil })
+
+	require.Eventually(t, func() bool {
+		s := m.Snapshot()
+		return s.Running == 1 && s.Queued == 1
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	require.NoError(t, pool.Shutdown(context.Background()))
+	s := m.Snapshot()
+	require.Equal(t, int64(2), s.Done)
+	require.Equal(t, int64(0), s.Queued)
+}
+
+func TestMetricsSharedAbort(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	m := NewMetrics(0)
+	pool, err := NewPool(Options{Workers: 1, Observer: m})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	pool.Submit(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	pool.Submit(func(ctx context.Context) error { return nil })
+	require.Eventually(t, func() bool {
+		s := m.Snapshot()
+		return s.Running == 1 && s.Queued == 1
+	}, time.Second, time.Millisecond)
+
+	// остановка другого запуска с тем же Metrics не трогает очередь пула
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = RunContext(ctx, []ContextTask{func(ctx context.Context) error { return nil }}, Options{
+		Workers:   1,
+		MaxErrors: 1,
+		Observer:  m,
+	})
+	require.True(t, errors.Is(err, context.Canceled), "actual err - %v", err)
+	s := m.Snapshot()
+	require.Equal(t, int64(1), s.Aborts)
+	require.Equal(t, int64(1), s.Queued)
+
+	close(release)
+	require.NoError(t, pool.Shutdown(context.Background()))
+	s = m.Snapshot()
+	require.Equal(t, int64(2), s.Done)
+	require.Equal(t, int64(0), s.Queued)
+}
+
+func TestMetricsRateLimitSkip(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	m := NewMetrics(0)
+	tasks := make([]ContextTask, 3)
+	for i := range tasks {
+		tasks[i] = func(ctx context.Context) error { return nil }
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resCh := make(chan Result)
+	go func() {
+		// часы не двигаются, поэтому после первой задачи токенов больше не будет
+		res, _ := RunContext(ctx, tasks, Options{
+			Workers:   3,
+			MaxErrors: 1,
+			RateLimit: 1,
+			Clock:     clock.NewMock(),
+			Observer:  m,
+		})
+		resCh <- res
+	}()
+
+	require.Eventually(t, func() bool {
+		return m.Snapshot().Done == 1
+	}, time.Second, time.Millisecond)
+	cancel()
+	res := <-resCh
+
+	skipped := 0
+	for _, r := range res.Tasks {
+		if r.Skipped {
+			skipped++
+		}
+	}
+	require.Equal(t, 2, skipped)
+	s := m.Snapshot()
+	// задачи, не дождавшиеся лимита, не считаются запущенными и выполненными
+	require.Equal(t, int64(1), s.Done)
+	require.Equal(t, int64(0), s.Running)
+	require.Equal(t, int64(0), s.Queued)
+}
+
+func TestMetricsPrometheus(t *testing.T) {
+	m := NewMetrics(10)
+	m.OnQueue(0)
+	m.OnStart(0)
+	m.OnFinish(0, nil, 1500*time.Millisecond)
+	m.OnQueue(1)
+	m.OnStart(1)
+	m.OnFinish(1, errors.New("task error"), 500*time.Millisecond)
+	m.OnQueue(2)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, m.WritePrometheus(buf, "hw05"))
+	expected := `# HELP hw05_tasks_queued Tasks waiting for a worker.
+# TYPE hw05_tasks_queued gauge
+hw05_tasks_queued 1
+# HELP hw05_tasks_running Tasks being executed.
+# TYPE hw05_tasks_running gauge
+hw05_tasks_running 0
+# HELP hw05_tasks_done_total Finished tasks.
+# TYPE hw05_tasks_done_total counter
+hw05_tasks_done_total 2
+# HELP hw05_tasks_failed_total Tasks finished with an error.
+# TYPE hw05_tasks_failed_total counter
+hw05_tasks_failed_total 1
+# HELP hw05_tasks_retries_total Task retries.
+# TYPE hw05_tasks_retries_total counter
+hw05_tasks_retries_total 0
+# HELP hw05_tasks_aborts_total Aborted runs.
+# TYPE hw05_tasks_aborts_total counter
+hw05_tasks_aborts_total 0
+# HELP hw05_task_duration_seconds Task duration over the latest tasks.
+# TYPE hw05_task_duration_seconds summary
+hw05_task_duration_seconds{quantile="0.5"} 0.5
+hw05_task_duration_seconds{quantile="0.9"} 1.5
+hw05_task_duration_seconds{quantile="0.99"} 1.5
+hw05_task_duration_seconds_sum 2
+hw05_task_duration_seconds_count 2
+`
+	require.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,28 @@
+package hw05parallelexecution
+
+import "time"
+
+// Observer получает события выполнения задач. Методы вызываются из воркеров конкурентно
+// и не должны блокироваться надолго.
+type Observer interface {
+	// OnQueue - задача получена из источника (или отправлена в Pool) и ждет свободного воркера
+	OnQueue(index int)
+	OnStart(index int)
+	// OnSkip - задача снята с очереди и запущена не будет (остановка запуска или отмена
+	// контекста при ожидании лимита скорости)
+	OnSkip(index int)
+	// OnRetry - попытка attempt завершилась ошибкой err, задача будет запущена повторно
+	OnRetry(index int, attempt int, err error)
+	OnFinish(index int, err error, duration time.Duration)
+	// OnAbort - запуск остановлен, для каждой невыполненной задачи из очереди уже вызван OnSkip
+	OnAbort(err error)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) OnQueue(int)                        {}
+func (nopObserver) OnStart(int)                        {}
+func (nopObserver) OnSkip(int)                         {}
+func (nopObserver) OnRetry(int, int, error)            {}
+func (nopObserver) OnFinish(int, error, time.Duration) {}
+func (nopObserver) OnAbort(error)                      {}
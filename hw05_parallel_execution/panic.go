@@ -0,0 +1,44 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+var ErrTaskPanicked = errors.New("task panicked")
+
+// PanicError - паника внутри задачи, превращенная в ошибку задачи.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrTaskPanicked, e.Value)
+}
+
+func (e *PanicError) Is(target error) bool {
+	return target == ErrTaskPanicked // nolint:errorlint
+}
+
+// Unwrap позволяет добраться до ошибки, переданной в panic.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+func safeCall(ctx context.Context, task ContextTask) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{
+				Value: r,
+				Stack: debug.Stack(),
+			}
+		}
+	}()
+	return task(ctx)
+}
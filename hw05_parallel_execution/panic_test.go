@@ -0,0 +1,52 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestRunPanic(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	errInner := errors.New("inner error")
+	tasks := []ContextTask{
+		func(ctx context.Context) error { panic("boom") },
+		func(ctx context.Context) error { panic(errInner) },
+		func(ctx context.Context) error { return nil },
+	}
+
+	for _, timeout := range []time.Duration{0, time.Minute} {
+		res, err := RunContext(context.Background(), tasks, Options{
+			Workers:     2,
+			MaxErrors:   3,
+			TaskTimeout: timeout,
+		})
+		require.True(t, errors.Is(err, ErrTaskPanicked), "actual err - %v", err)
+		require.True(t, errors.Is(err, errInner), "actual err - %v", err)
+
+		var panicErr *PanicError
+		require.True(t, errors.As(res.Tasks[0].Err, &panicErr))
+		require.Equal(t, "boom", panicErr.Value)
+		require.Contains(t, string(panicErr.Stack), "panic_test.go")
+		require.EqualError(t, panicErr, "task panicked: boom")
+
+		require.NoError(t, res.Tasks[2].Err)
+	}
+}
+
+func TestRunLegacyPanic(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	tasks := []Task{
+		func() error { panic("boom") },
+		func() error { return nil },
+	}
+	err := Run(tasks, 1, 1)
+	require.True(t, errors.Is(err, ErrErrorsLimitExceeded), "actual err - %v", err)
+	require.True(t, errors.Is(err, ErrTaskPanicked), "actual err - %v", err)
+}
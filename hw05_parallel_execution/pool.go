@@ -0,0 +1,229 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrPoolClosed    = errors.New("pool closed")
+	ErrTaskAbandoned = errors.New("task abandoned")
+)
+
+// Future - результат задачи, отправленной в Pool.
+type Future struct {
+	done chan struct{}
+	res  TaskResult
+}
+
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+func (f *Future) resolve(res TaskResult) {
+	f.res = res
+	close(f.done)
+}
+
+// Done закрывается, когда задача завершилась или была отброшена.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait ждет завершения задачи и возвращает ее ошибку или ошибку ctx.
+func (f *Future) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-f.done:
+		return f.res.Err
+	}
+}
+
+// Result возвращает результат задачи; до закрытия Done результат пустой.
+func (f *Future) Result() TaskResult {
+	select {
+	case <-f.done:
+		return f.res
+	default:
+		return TaskResult{}
+	}
+}
+
+type poolJob struct {
+	job
+	future *Future
+}
+
+// Pool - долгоживущий набор воркеров. Задачи выполняются с учетом повторов, таймаутов
+// и ограничения скорости из Options; лимиты ошибок в Pool не применяются.
+type Pool struct {
+	executor *TaskExecutor
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []*poolJob
+	workers int
+	running int
+	closed  bool
+	seq     int
+	wg      sync.WaitGroup
+}
+
+func NewPool(opts Options) (*Pool, error) {
+	executor, err := NewTaskExecutor(opts)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		executor: executor,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	p.cond = sync.NewCond(&p.mu)
+	p.mu.Lock()
+	p.resize(opts.Workers)
+	p.mu.Unlock()
+	return p, nil
+}
+
+func (p *Pool) Submit(task ContextTask) *Future {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.submit(task)
+}
+
+// SubmitBatch ставит задачи в очередь атомарно: либо все, либо ни одной, если пул закрыт.
+func (p *Pool) SubmitBatch(tasks []ContextTask) []*Future {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	futures := make([]*Future, 0, len(tasks))
+	for _, task := range tasks {
+		futures = append(futures, p.submit(task))
+	}
+	return futures
+}
+
+func (p *Pool) submit(task ContextTask) *Future {
+	future := newFuture()
+	if p.closed {
+		future.resolve(TaskResult{Err: ErrPoolClosed, Skipped: true})
+		return future
+	}
+	p.queue = append(p.queue, &poolJob{
+		job:    job{index: p.seq, task: task},
+		future: future,
+	})
+	p.executor.observer.OnQueue(p.seq)
+	p.seq++
+	p.cond.Signal()
+	return future
+}
+
+// Resize меняет число воркеров. Лишние воркеры завершаются после текущей задачи.
+func (p *Pool) Resize(n int) error {
+	if n <= 0 {
+		return ErrErrorsNegativeWorkers
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	p.resize(n)
+	return nil
+}
+
+func (p *Pool) resize(n int) {
+	p.workers = n
+	for ; p.running < p.workers; p.running++ {
+		p.wg.Add(1)
+		go p.runWorker()
+	}
+	p.cond.Broadcast()
+}
+
+// Workers возвращает текущее целевое число воркеров.
+func (p *Pool) Workers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.workers
+}
+
+// Queued возвращает число задач, ожидающих свободного воркера.
+func (p *Pool) Queued() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.queue)
+}
+
+func (p *Pool) runWorker() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		for len(p.queue) == 0 && !p.closed && p.running <= p.workers {
+			p.cond.Wait()
+		}
+		if p.running > p.workers || len(p.queue) == 0 {
+			// пул уменьшили или закрыли и очередь пуста
+			p.running--
+			p.mu.Unlock()
+			return
+		}
+		j := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.mu.Unlock()
+
+		j.future.resolve(p.executor.runTask(p.ctx, j.job))
+	}
+}
+
+// Shutdown перестает принимать задачи и ждет, пока воркеры выполнят всю очередь.
+// Если ctx отменен раньше, оставшиеся задачи отбрасываются как в Stop.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Stop перестает принимать задачи, отбрасывает очередь с ошибкой ErrTaskAbandoned,
+// отменяет контекст выполняющихся задач и ждет завершения воркеров.
+func (p *Pool) Stop() {
+	p.mu.Lock()
+	p.closed = true
+	queue := p.queue
+	p.queue = nil
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	for _, j := range queue {
+		p.executor.observer.OnSkip(j.index)
+		j.future.resolve(TaskResult{Index: j.index, Err: ErrTaskAbandoned, Skipped: true})
+	}
+	if len(queue) > 0 {
+		p.executor.observer.OnAbort(ErrTaskAbandoned)
+	}
+	p.cancel()
+	p.wg.Wait()
+}
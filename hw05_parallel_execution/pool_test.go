@@ -0,0 +1,140 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestPool(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("submit and wait", func(t *testing.T) {
+		pool, err := NewPool(Options{Workers: 2})
+		require.NoError(t, err)
+
+		errTask := errors.New("task error")
+		ok := pool.Submit(func(ctx context.Context) error { return nil })
+		failed := pool.Submit(func(ctx context.Context) error { return errTask })
+
+		require.NoError(t, ok.Wait(context.Background()))
+		require.True(t, errors.Is(failed.Wait(context.Background()), errTask))
+		require.Equal(t, 1, failed.Result().Index)
+		require.Equal(t, 1, failed.Result().Attempts)
+
+		require.NoError(t, pool.Shutdown(context.Background()))
+		closed := pool.Submit(func(ctx context.Context) error { return nil })
+		require.True(t, errors.Is(closed.Wait(context.Background()), ErrPoolClosed))
+	})
+
+	t.Run("shutdown drains queue", func(t *testing.T) {
+		pool, err := NewPool(Options{Workers: 3})
+		require.NoError(t, err)
+
+		var count int32
+		tasks := make([]ContextTask, 0, 50)
+		for i := 0; i < 50; i++ {
+			tasks = append(tasks, func(ctx context.Context) error {
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&count, 1)
+				return nil
+			})
+		}
+		futures := pool.SubmitBatch(tasks)
+		require.NoError(t, pool.Shutdown(context.Background()))
+		require.Equal(t, int32(50), atomic.LoadInt32(&count))
+		for _, f := range futures {
+			select {
+			case <-f.Done():
+			default:
+				t.Fatal("future is not resolved")
+			}
+		}
+	})
+
+	t.Run("stop abandons queue", func(t *testing.T) {
+		pool, err := NewPool(Options{Workers: 1})
+		require.NoError(t, err)
+
+		started := make(chan struct{})
+		running := pool.Submit(func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		queued := pool.Submit(func(ctx context.Context) error { return nil })
+		<-started
+		require.Equal(t, 1, pool.Queued())
+
+		pool.Stop()
+		require.True(t, errors.Is(running.Wait(context.Background()), context.Canceled))
+		require.True(t, errors.Is(queued.Wait(context.Background()), ErrTaskAbandoned))
+		require.True(t, queued.Result().Skipped)
+	})
+
+	t.Run("shutdown deadline", func(t *testing.T) {
+		pool, err := NewPool(Options{Workers: 1})
+		require.NoError(t, err)
+
+		pool.Submit(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		queued := pool.Submit(func(ctx context.Context) error { return nil })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err = pool.Shutdown(ctx)
+		require.True(t, errors.Is(err, context.DeadlineExceeded), "actual err - %v", err)
+		require.True(t, errors.Is(queued.Wait(context.Background()), ErrTaskAbandoned))
+	})
+
+	t.Run("resize", func(t *testing.T) {
+		pool, err := NewPool(Options{Workers: 1})
+		require.NoError(t, err)
+		require.True(t, errors.Is(pool.Resize(0), ErrErrorsNegativeWorkers))
+
+		var running int32
+		release := make(chan struct{})
+		task := func(ctx context.Context) error {
+			atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			<-release
+			return nil
+		}
+		futures := pool.SubmitBatch([]ContextTask{task, task, task, task})
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&running) == 1
+		}, time.Second, time.Millisecond)
+
+		require.NoError(t, pool.Resize(4))
+		require.Equal(t, 4, pool.Workers())
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&running) == 4
+		}, time.Second, time.Millisecond)
+		close(release)
+		for _, f := range futures {
+			require.NoError(t, f.Wait(context.Background()))
+		}
+
+		require.NoError(t, pool.Resize(2))
+		require.Eventually(t, func() bool {
+			pool.mu.Lock()
+			defer pool.mu.Unlock()
+			return pool.running == 2
+		}, time.Second, time.Millisecond)
+
+		require.NoError(t, pool.Shutdown(context.Background()))
+		require.True(t, errors.Is(pool.Resize(3), ErrPoolClosed))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := NewPool(Options{Workers: 0})
+		require.True(t, errors.Is(err, ErrErrorsNegativeWorkers), "actual err - %v", err)
+	})
+}
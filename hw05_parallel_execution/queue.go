@@ -0,0 +1,189 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrQueueClosed   = errors.New("queue closed")
+	ErrInvalidWeight = errors.New("group weight should be > 0")
+)
+
+const defaultWeight = 1
+
+// Priority - класс задачи; задачи более высокого класса всегда выдаются раньше.
+type Priority int
+
+const (
+	PriorityBulk Priority = iota
+	PriorityNormal
+	PriorityInteractive
+)
+
+// FairQueue - источник задач с классами приоритета и взвешенной справедливой очередью
+// между группами (например, арендаторами) внутри одного класса. Группа с весом 3 получает
+// втрое больше запусков, чем группа с весом 1, пока у обеих есть задачи.
+type FairQueue struct {
+	mu      sync.Mutex
+	classes []*priorityClass // по убыванию приоритета
+	weights map[string]int
+	size    int
+	closed  bool
+	notify  chan struct{}
+}
+
+type priorityClass struct {
+	priority Priority
+	groups   []*fairGroup
+	byName   map[string]*fairGroup
+	// vclock - виртуальное время последней выданной задачи
+	vclock float64
+}
+
+type fairGroup struct {
+	name   string
+	weight int
+	tasks  []ContextTask
+	vtime  float64
+}
+
+func (g *fairGroup) finish() float64 {
+	return g.vtime + 1/float64(g.weight)
+}
+
+func NewFairQueue() *FairQueue {
+	return &FairQueue{
+		weights: make(map[string]int),
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+// SetWeight задает вес группы, по умолчанию вес равен 1.
+func (q *FairQueue) SetWeight(group string, weight int) error {
+	if weight <= 0 {
+		return ErrInvalidWeight
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.weights[group] = weight
+	for _, class := range q.classes {
+		if g, ok := class.byName[group]; ok {
+			g.weight = weight
+		}
+	}
+	return nil
+}
+
+func (q *FairQueue) Push(task ContextTask, priority Priority, group string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	class := q.class(priority)
+	g, ok := class.byName[group]
+	if !ok {
+		weight, ok := q.weights[group]
+		if !ok {
+			weight = defaultWeight
+		}
+		g = &fairGroup{name: group, weight: weight}
+		class.byName[group] = g
+		class.groups = append(class.groups, g)
+	}
+	if len(g.tasks) == 0 && g.vtime < class.vclock {
+		// простаивавшая группа не накапливает кредит
+		g.vtime = class.vclock
+	}
+	g.tasks = append(g.tasks, task)
+	q.size++
+	q.signal()
+	return nil
+}
+
+func (q *FairQueue) class(priority Priority) *priorityClass {
+	for _, class := range q.classes {
+		if class.priority == priority {
+			return class
+		}
+	}
+	class := &priorityClass{
+		priority: priority,
+		byName:   make(map[string]*fairGroup),
+	}
+	q.classes = append(q.classes, class)
+	sort.Slice(q.classes, func(i, j int) bool {
+		return q.classes[i].priority > q.classes[j].priority
+	})
+	return class
+}
+
+// Close запрещает добавлять задачи; Next вернет false, когда очередь опустеет.
+func (q *FairQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.signal()
+}
+
+func (q *FairQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *FairQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+func (q *FairQueue) pop() ContextTask {
+	for _, class := range q.classes {
+		var next *fairGroup
+		for _, g := range class.groups {
+			if len(g.tasks) > 0 && (next == nil || g.finish() < next.finish()) {
+				next = g
+			}
+		}
+		if next == nil {
+			continue
+		}
+		task := next.tasks[0]
+		next.tasks[0] = nil
+		next.tasks = next.tasks[1:]
+		class.vclock = next.vtime
+		next.vtime = next.finish()
+		q.size--
+		return task
+	}
+	return nil
+}
+
+func (q *FairQueue) Next(ctx context.Context) (ContextTask, bool) {
+	for {
+		q.mu.Lock()
+		task := q.pop()
+		closed := q.closed
+		if q.size > 0 || closed {
+			// передаем сигнал дальше, если очередь читают несколько получателей
+			q.signal()
+		}
+		q.mu.Unlock()
+		if task != nil {
+			return task, true
+		}
+		if closed {
+			return nil, false
+		}
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-q.notify:
+		}
+	}
+}
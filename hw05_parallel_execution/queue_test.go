@@ -0,0 +1,131 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestFairQueuePriority(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	mu := sync.Mutex{}
+	order := make([]string, 0)
+	task := func(name string) ContextTask {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	q := NewFairQueue()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, q.Push(task("bulk"), PriorityBulk, "batch"))
+	}
+	require.NoError(t, q.Push(task("normal"), PriorityNormal, "batch"))
+	require.NoError(t, q.Push(task("interactive"), PriorityInteractive, "user"))
+	q.Close()
+	require.Equal(t, 7, q.Len())
+	require.True(t, errors.Is(q.Push(task("late"), PriorityBulk, "batch"), ErrQueueClosed))
+
+	err := RunSource(context.Background(), q, Options{Workers: 1, MaxErrors: 1})
+	require.NoError(t, err)
+	require.Equal(t, []string{"interactive", "normal", "bulk", "bulk", "bulk", "bulk", "bulk"}, order)
+	require.Equal(t, 0, q.Len())
+}
+
+func TestFairQueueWeights(t *testing.T) {
+	q := NewFairQueue()
+	require.True(t, errors.Is(q.SetWeight("a", 0), ErrInvalidWeight))
+	require.NoError(t, q.SetWeight("a", 3))
+
+	tasks := make(map[string]int)
+	names := make(map[*int]string)
+	push := func(group string) {
+		marker := new(int)
+		names[marker] = group
+		require.NoError(t, q.Push(func(ctx context.Context) error {
+			*marker++
+			return nil
+		}, PriorityNormal, group))
+	}
+	for i := 0; i < 12; i++ {
+		push("a")
+		push("b")
+	}
+
+	// на каждые 4 выдачи приходится 3 задачи группы a и 1 задача группы b
+	for round := 1; round <= 4; round++ {
+		for i := 0; i < 4; i++ {
+			task, ok := q.Next(context.Background())
+			require.True(t, ok)
+			require.NoError(t, task(context.Background()))
+		}
+		tasks["a"], tasks["b"] = 0, 0
+		for marker, group := range names {
+			tasks[group] += *marker
+		}
+		require.Equal(t, 3*round, tasks["a"])
+		require.Equal(t, round, tasks["b"])
+	}
+}
+
+func TestFairQueueIdleGroup(t *testing.T) {
+	q := NewFairQueue()
+	got := make([]string, 0)
+	push := func(group string) {
+		require.NoError(t, q.Push(func(ctx context.Context) error {
+			got = append(got, group)
+			return nil
+		}, PriorityNormal, group))
+	}
+	next := func() {
+		task, ok := q.Next(context.Background())
+		require.True(t, ok)
+		require.NoError(t, task(context.Background()))
+	}
+
+	// группа bulk долго работает одна
+	for i := 0; i < 10; i++ {
+		push("bulk")
+		next()
+	}
+	// пришедшая позже группа не получает все накопленное время, а чередуется с bulk
+	for i := 0; i < 4; i++ {
+		push("bulk")
+		push("new")
+	}
+	for i := 0; i < 8; i++ {
+		next()
+	}
+	require.Equal(t, []string{"new", "bulk", "new", "bulk", "new", "bulk", "new", "bulk"}, got[10:])
+}
+
+func TestFairQueueWaitsForTasks(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	q := NewFairQueue()
+	done := make(chan error)
+	var count int
+	go func() {
+		done <- RunSource(context.Background(), q, Options{Workers: 1, MaxErrors: 1})
+	}()
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, q.Push(func(ctx context.Context) error {
+			count++
+			return nil
+		}, PriorityNormal, ""))
+	}
+	q.Close()
+	require.NoError(t, <-done)
+	require.Equal(t, 3, count)
+}
@@ -0,0 +1,70 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+var (
+	ErrErrorsNegativeRateLimit = errors.New("rate limit should be >= 0")
+	ErrErrorsNegativeTimeout   = errors.New("task timeout should be >= 0")
+	ErrTaskTimeout             = errors.New("task timeout")
+)
+
+// tokenBucket пропускает rate запусков в секунду, накапливая не больше burst токенов.
+type tokenBucket struct {
+	mu     sync.Mutex
+	clock  clock.Clock
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(c clock.Clock, rate float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBucket{
+		clock:  c,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   c.Now(),
+	}
+}
+
+// reserve забирает токен или возвращает, сколько нужно подождать до появления следующего.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		wait := b.reserve()
+		if wait == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.clock.After(wait):
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestTokenBucket(t *testing.T) {
+	mock := clock.NewMock()
+	bucket := newTokenBucket(mock, 2, 3)
+
+	for i := 0; i < 3; i++ {
+		require.Equal(t, time.Duration(0), bucket.reserve())
+	}
+	require.Equal(t, 500*time.Millisecond, bucket.reserve())
+
+	mock.Add(500 * time.Millisecond)
+	require.Equal(t, time.Duration(0), bucket.reserve())
+
+	// накопленные токены не превышают burst
+	mock.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		require.Equal(t, time.Duration(0), bucket.reserve())
+	}
+	require.NotEqual(t, time.Duration(0), bucket.reserve())
+}
+
+func TestRunRateLimit(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	mock := newAfterClock()
+	tasksCount := 6
+	var runTasksCount int32
+	tasks := make([]ContextTask, 0, tasksCount)
+	for i := 0; i < tasksCount; i++ {
+		tasks = append(tasks, func(ctx context.Context) error {
+			atomic.AddInt32(&runTasksCount, 1)
+			return nil
+		})
+	}
+	opts := Options{
+		Workers:   3,
+		MaxErrors: 1,
+		RateLimit: 1,
+		Burst:     2,
+		Clock:     mock,
+	}
+
+	errCh := make(chan error)
+	go func() {
+		_, err := RunContext(context.Background(), tasks, opts)
+		errCh <- err
+	}()
+
+	// burst пропускает две задачи сразу, остальные - по одной в секунду
+	<-mock.waits
+	require.Equal(t, int32(2), atomic.LoadInt32(&runTasksCount))
+	for i := 3; i <= tasksCount; i++ {
+		mock.Add(time.Second)
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&runTasksCount) == int32(i)
+		}, time.Second, time.Millisecond)
+		if i < tasksCount {
+			<-mock.waits
+		}
+	}
+	require.NoError(t, <-errCh)
+}
+
+func TestRunTaskTimeout(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	mock := clock.NewMock()
+	release := make(chan struct{})
+	var started, finished int32
+	tasks := []ContextTask{
+		func(ctx context.Context) error {
+			atomic.AddInt32(&started, 1)
+			// задача игнорирует контекст и висит, пока ее не отпустят
+			<-release
+			return nil
+		},
+		func(ctx context.Context) error {
+			atomic.AddInt32(&finished, 1)
+			return nil
+		},
+	}
+	opts := Options{
+		Workers:     1,
+		MaxErrors:   2,
+		TaskTimeout: time.Minute,
+		Clock:       mock,
+	}
+
+	resCh := make(chan Result)
+	errCh := make(chan error)
+	go func() {
+		res, err := RunContext(context.Background(), tasks, opts)
+		resCh <- res
+		errCh <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&started) == 1
+	}, time.Second, time.Millisecond)
+	mock.Add(time.Minute)
+
+	res := <-resCh
+	err := <-errCh
+	close(release)
+
+	require.True(t, errors.Is(err, ErrTaskTimeout), "actual err - %v", err)
+	require.True(t, errors.Is(res.Tasks[0].Err, ErrTaskTimeout))
+	require.NoError(t, res.Tasks[1].Err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&finished))
+}
+
+func TestRunTaskTimeoutCancel(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	tasks := []ContextTask{
+		func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error)
+	go func() {
+		_, err := RunContext(ctx, tasks, Options{Workers: 1, MaxErrors: 1, TaskTimeout: time.Minute})
+		errCh <- err
+	}()
+
+	<-started
+	cancel()
+
+	// запуск завершается сразу, не дожидаясь зависшей задачи и таймаута
+	select {
+	case err := <-errCh:
+		require.True(t, errors.Is(err, context.Canceled), "actual err - %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("RunContext did not return after cancel")
+	}
+	close(release)
+}
+
+func TestRunNegativeLimits(t *testing.T) {
+	_, err := RunContext(context.Background(), nil, Options{Workers: 1, RateLimit: -1})
+	require.True(t, errors.Is(err, ErrErrorsNegativeRateLimit), "actual err - %v", err)
+
+	_, err = RunContext(context.Background(), nil, Options{Workers: 1, TaskTimeout: -time.Second})
+	require.True(t, errors.Is(err, ErrErrorsNegativeTimeout), "actual err - %v", err)
+}
@@ -0,0 +1,104 @@
+package hw05parallelexecution
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TaskResult struct {
+	Index int
+	// Name - имя задачи в Graph
+	Name     string
+	Err      error
+	Duration time.Duration
+	// Attempts - сколько раз задача запускалась с учетом повторов
+	Attempts int
+	// Skipped - задача не запускалась: лимит ошибок превышен, контекст отменен
+	// или упала зависимость в Graph (тогда Err содержит ErrDependencyFailed)
+	Skipped bool
+}
+
+type Result struct {
+	Tasks []TaskResult
+}
+
+// Failed возвращает результаты задач, завершившихся с ошибкой.
+func (r Result) Failed() []TaskResult {
+	failed := make([]TaskResult, 0)
+	for _, task := range r.Tasks {
+		if task.Err != nil && !task.Skipped {
+			failed = append(failed, task)
+		}
+	}
+	return failed
+}
+
+func (r Result) err(cause error) error {
+	failed := r.Failed()
+	if cause == nil && len(failed) == 0 {
+		return nil
+	}
+	return &RunError{
+		Cause: cause,
+		Tasks: failed,
+	}
+}
+
+// RunError объединяет причину остановки и ошибки задач.
+// errors.Is и errors.As проверяют как Cause, так и ошибку каждой задачи.
+type RunError struct {
+	// Cause - ErrErrorsLimitExceeded, ошибка контекста или nil, если все задачи были запущены
+	Cause error
+	Tasks []TaskResult
+	// Omitted - сколько упавших задач не попало в Tasks (см. RunSource)
+	Omitted int
+}
+
+func (e *RunError) Error() string {
+	s := strings.Builder{}
+	if e.Cause != nil {
+		s.WriteString(e.Cause.Error())
+	} else {
+		s.WriteString("tasks failed")
+	}
+	for i, task := range e.Tasks {
+		if i == 0 {
+			s.WriteString(": ")
+		} else {
+			s.WriteString("; ")
+		}
+		if task.Name != "" {
+			s.WriteString(fmt.Sprintf("task %s: %s", task.Name, task.Err))
+		} else {
+			s.WriteString(fmt.Sprintf("task %d: %s", task.Index, task.Err))
+		}
+	}
+	if e.Omitted > 0 {
+		s.WriteString(fmt.Sprintf("; and %d more", e.Omitted))
+	}
+	return s.String()
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Cause
+}
+
+func (e *RunError) Is(target error) bool {
+	for _, task := range e.Tasks {
+		if errors.Is(task.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *RunError) As(target interface{}) bool {
+	for _, task := range e.Tasks {
+		if errors.As(task.Err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package hw05parallelexecution
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+var ErrErrorsNegativeAttempts = errors.New("retry attempts should be >= 0")
+
+const defaultBackoffMultiplier = 2
+
+// RetryPolicy описывает повторный запуск упавших задач.
+// В лимит ошибок засчитывается только ошибка последней попытки.
+type RetryPolicy struct {
+	// MaxAttempts - общее число попыток, включая первую; 0 или 1 - без повторов
+	MaxAttempts int
+	// InitialBackoff - пауза перед второй попыткой, каждая следующая больше в Multiplier раз
+	InitialBackoff time.Duration
+	// MaxBackoff ограничивает паузу сверху, 0 - без ограничения
+	MaxBackoff time.Duration
+	// Multiplier по умолчанию 2
+	Multiplier float64
+	// Jitter - доля паузы от 0 до 1, на которую она случайно уменьшается
+	Jitter float64
+	// Retryable выбирает ошибки, которые стоит повторить; nil - повторять любые
+	Retryable func(err error) bool
+}
+
+func (p RetryPolicy) validate() error {
+	if p.MaxAttempts < 0 {
+		return ErrErrorsNegativeAttempts
+	}
+	return nil
+}
+
+func (p RetryPolicy) retryable(attempt int, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// backoff возвращает паузу после попытки номер attempt (нумерация с 1).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = defaultBackoffMultiplier
+	}
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d -= d * math.Min(p.Jitter, 1) * rand.Float64() // nolint:gosec
+	}
+	// без MaxBackoff пауза на поздних попытках не помещается в time.Duration
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
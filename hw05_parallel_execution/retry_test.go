@@ -0,0 +1,213 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+// afterClock сообщает о каждой запрошенной паузе, чтобы тест двигал часы только после нее.
+type afterClock struct {
+	*clock.Mock
+	waits chan time.Duration
+}
+
+func newAfterClock() *afterClock {
+	return &afterClock{
+		Mock:  clock.NewMock(),
+		waits: make(chan time.Duration),
+	}
+}
+
+func (c *afterClock) After(d time.Duration) <-chan time.Time {
+	ch := c.Mock.After(d)
+	c.waits <- d
+	return ch
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, want := range expected {
+		require.Equal(t, want, policy.backoff(i+1))
+	}
+
+	policy.Multiplier = 3
+	require.Equal(t, 900*time.Millisecond, policy.backoff(3))
+
+	// без MaxBackoff пауза растет до максимальной, а не переполняется
+	unbounded := RetryPolicy{MaxAttempts: 100, InitialBackoff: time.Second}
+	require.Equal(t, time.Duration(math.MaxInt64), unbounded.backoff(40))
+	require.Equal(t, time.Duration(math.MaxInt64), unbounded.backoff(100))
+	unbounded.Jitter = 0.5
+	require.True(t, unbounded.backoff(40) > 0)
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := policy.backoff(2)
+		require.GreaterOrEqual(t, int64(d), int64(150*time.Millisecond))
+		require.LessOrEqual(t, int64(d), int64(300*time.Millisecond))
+	}
+}
+
+func TestRunRetry(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	errTemporary := errors.New("temporary error")
+	errPermanent := errors.New("permanent error")
+
+	t.Run("retried until success", func(t *testing.T) {
+		mock := newAfterClock()
+		var attempts int32
+		tasks := []ContextTask{
+			func(ctx context.Context) error {
+				if atomic.AddInt32(&attempts, 1) < 3 {
+					return errTemporary
+				}
+				return nil
+			},
+		}
+		opts := Options{
+			Workers:   1,
+			MaxErrors: 1,
+			Clock:     mock,
+			Retry: RetryPolicy{
+				MaxAttempts:    5,
+				InitialBackoff: time.Second,
+			},
+		}
+
+		resCh := make(chan Result)
+		errCh := make(chan error)
+		go func() {
+			res, err := RunContext(context.Background(), tasks, opts)
+			resCh <- res
+			errCh <- err
+		}()
+
+		require.Equal(t, time.Second, <-mock.waits)
+		mock.Add(time.Second)
+		require.Equal(t, 2*time.Second, <-mock.waits)
+		mock.Add(2 * time.Second)
+
+		res := <-resCh
+		require.NoError(t, <-errCh)
+		require.Equal(t, 3, res.Tasks[0].Attempts)
+		require.Equal(t, 3*time.Second, res.Tasks[0].Duration)
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		mock := newAfterClock()
+		var attempts int32
+		tasks := []ContextTask{
+			func(ctx context.Context) error {
+				atomic.AddInt32(&attempts, 1)
+				return errTemporary
+			},
+		}
+		opts := Options{
+			Workers:   1,
+			MaxErrors: 1,
+			Clock:     mock,
+			Retry: RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Second,
+			},
+		}
+
+		errCh := make(chan error)
+		go func() {
+			_, err := RunContext(context.Background(), tasks, opts)
+			errCh <- err
+		}()
+		for i := 0; i < 2; i++ {
+			mock.Add(<-mock.waits)
+		}
+
+		err := <-errCh
+		require.True(t, errors.Is(err, ErrErrorsLimitExceeded), "actual err - %v", err)
+		require.True(t, errors.Is(err, errTemporary), "actual err - %v", err)
+		require.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("not retryable errors", func(t *testing.T) {
+		var attempts int32
+		tasks := []ContextTask{
+			func(ctx context.Context) error {
+				atomic.AddInt32(&attempts, 1)
+				return errPermanent
+			},
+		}
+		opts := Options{
+			Workers:   1,
+			MaxErrors: 2,
+			Clock:     newAfterClock(),
+			Retry: RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Second,
+				Retryable: func(err error) bool {
+					return errors.Is(err, errTemporary)
+				},
+			},
+		}
+
+		res, err := RunContext(context.Background(), tasks, opts)
+		require.True(t, errors.Is(err, errPermanent), "actual err - %v", err)
+		require.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+		require.Equal(t, 1, res.Tasks[0].Attempts)
+	})
+
+	t.Run("cancel during backoff", func(t *testing.T) {
+		mock := newAfterClock()
+		tasks := []ContextTask{
+			func(ctx context.Context) error { return errTemporary },
+		}
+		opts := Options{
+			Workers:   1,
+			MaxErrors: 2,
+			Clock:     mock,
+			Retry: RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Second,
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() {
+			_, err := RunContext(ctx, tasks, opts)
+			errCh <- err
+		}()
+		<-mock.waits
+		cancel()
+
+		err := <-errCh
+		require.True(t, errors.Is(err, errTemporary), "actual err - %v", err)
+	})
+
+	t.Run("negative attempts", func(t *testing.T) {
+		_, err := RunContext(context.Background(), nil, Options{
+			Workers: 1,
+			Retry:   RetryPolicy{MaxAttempts: -1},
+		})
+		require.True(t, errors.Is(err, ErrErrorsNegativeAttempts), "actual err - %v", err)
+	})
+}
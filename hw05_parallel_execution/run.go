@@ -1,9 +1,13 @@
 package hw05parallelexecution
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"github.com/benbjohnson/clock"
 )
 
 var (
@@ -12,60 +16,292 @@ var (
 	ErrErrorsNegativeErrors  = errors.New("errors should be >= 0")
 )
 
+// MaxSourceFailures - сколько последних ошибок задач сохраняет RunSource.
+const MaxSourceFailures = 100
+
+type Options struct {
+	Workers   int
+	MaxErrors int
+	Retry     RetryPolicy
+	// RateLimit ограничивает число запусков задач в секунду (с учетом повторов), 0 - без ограничения
+	RateLimit float64
+	// Burst - сколько запусков можно сделать подряд без ожидания, по умолчанию 1
+	Burst int
+	// TaskTimeout ограничивает время одной попытки. Зависшая задача считается ошибкой ErrTaskTimeout,
+	// а воркер переходит к следующей, не дожидаясь ее завершения
+	TaskTimeout time.Duration
+	// IgnoreErrors - выполнить все задачи независимо от числа ошибок, MaxErrors не учитывается
+	IgnoreErrors bool
+	// ErrorRate с ненулевым окном заменяет MaxErrors ограничением на долю ошибок
+	ErrorRate ErrorRate
+	// Observer получает события выполнения задач, например, Metrics
+	Observer Observer
+	// Clock используется для замера времени и пауз между попытками, по умолчанию - системные часы
+	Clock clock.Clock
+}
+
 type TaskExecutor struct {
 	workers   int
 	errors    int32
 	maxErrors int32
+	retry     RetryPolicy
+	limiter   *tokenBucket
+	timeout   time.Duration
+	clock     clock.Clock
+	observer  Observer
+
+	ignoreErrors bool
+	errorRate    ErrorRate
+	window       *errorWindow
 }
 
 type Task func() error
 
-func (t *TaskExecutor) runWorker(wg *sync.WaitGroup, taskCh <-chan Task) {
+// ContextTask - задача, которая получает контекст запуска и должна завершаться при его отмене.
+type ContextTask func(ctx context.Context) error
+
+func (t Task) withContext() ContextTask {
+	return func(context.Context) error {
+		return t()
+	}
+}
+
+type job struct {
+	index int
+	task  ContextTask
+}
+
+func NewTaskExecutor(opts Options) (*TaskExecutor, error) {
+	if opts.Workers <= 0 {
+		return nil, ErrErrorsNegativeWorkers
+	}
+	if opts.MaxErrors < 0 {
+		return nil, ErrErrorsNegativeErrors
+	}
+	if err := opts.Retry.validate(); err != nil {
+		return nil, err
+	}
+	if err := opts.ErrorRate.validate(); err != nil {
+		return nil, err
+	}
+	if opts.RateLimit < 0 {
+		return nil, ErrErrorsNegativeRateLimit
+	}
+	if opts.TaskTimeout < 0 {
+		return nil, ErrErrorsNegativeTimeout
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.New()
+	}
+	if opts.Observer == nil {
+		opts.Observer = nopObserver{}
+	}
+	executor := &TaskExecutor{
+		maxErrors: int32(opts.MaxErrors),
+		workers:   opts.Workers,
+		retry:     opts.Retry,
+		timeout:   opts.TaskTimeout,
+		clock:     opts.Clock,
+		observer:  opts.Observer,
+
+		ignoreErrors: opts.IgnoreErrors,
+		errorRate:    opts.ErrorRate,
+	}
+	if opts.RateLimit > 0 {
+		executor.limiter = newTokenBucket(opts.Clock, opts.RateLimit, opts.Burst)
+	}
+	return executor, nil
+}
+
+func (t *TaskExecutor) limitExceeded() bool {
+	switch {
+	case t.ignoreErrors:
+		return false
+	case t.window != nil:
+		return t.window.exceeded()
+	default:
+		return atomic.LoadInt32(&t.errors) >= t.maxErrors
+	}
+}
+
+func (t *TaskExecutor) limitErr() error {
+	if t.window != nil {
+		return ErrErrorRateExceeded
+	}
+	return ErrErrorsLimitExceeded
+}
+
+func (t *TaskExecutor) record(res TaskResult) {
+	if res.Skipped {
+		return
+	}
+	if t.window != nil {
+		t.window.record(res.Err != nil)
+	}
+	if res.Err != nil {
+		atomic.AddInt32(&t.errors, 1)
+	}
+}
+
+func (t *TaskExecutor) runWorker(ctx context.Context, wg *sync.WaitGroup, jobCh <-chan job,
+	stop context.CancelFunc, report func(TaskResult),
+) {
 	defer wg.Done()
 	for {
-		task, ok := <-taskCh
+		j, ok := <-jobCh
 		if !ok {
 			// выходим, если все обработали и канал закрыт
 			return
 		}
-		if err := task(); err != nil {
-			atomic.AddInt32(&t.errors, 1)
+		res := t.runTask(ctx, j)
+		report(res)
+		t.record(res)
+		if t.limitExceeded() {
+			// будим диспетчер, если он ждет следующую задачу из источника
+			stop()
 		}
 	}
 }
 
-func (t *TaskExecutor) Run(tasks []Task) error {
-	taskCh := make(chan Task)
+// run выдает задачи из src воркерам и возвращает причину остановки: превышение лимита ошибок,
+// ошибку контекста или nil, если источник закончился.
+func (t *TaskExecutor) run(ctx context.Context, src TaskSource, report func(TaskResult)) error {
+	atomic.StoreInt32(&t.errors, 0)
+	t.window = nil
+	if t.errorRate.Window > 0 && !t.ignoreErrors {
+		t.window = newErrorWindow(t.errorRate)
+	}
+	stopCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	jobCh := make(chan job)
 	wg := &sync.WaitGroup{}
 	wg.Add(t.workers)
 	for i := 0; i < t.workers; i++ {
-		go t.runWorker(wg, taskCh)
+		go t.runWorker(ctx, wg, jobCh, stop, report)
 	}
-	for _, task := range tasks {
-		if atomic.LoadInt32(&t.errors) >= t.maxErrors {
+
+	var cause error
+dispatch:
+	for i := 0; !t.limitExceeded(); i++ {
+		if ctx.Err() != nil {
+			cause = ctx.Err()
+			break
+		}
+		task, ok := src.Next(stopCtx)
+		if !ok {
+			cause = ctx.Err()
+			break
+		}
+		if t.limitExceeded() {
 			break
 		}
-		taskCh <- task
+		t.observer.OnQueue(i)
+		select {
+		case <-stopCtx.Done():
+			t.observer.OnSkip(i)
+			cause = ctx.Err()
+			break dispatch
+		case jobCh <- job{index: i, task: task}:
+		}
 	}
 	// Как записали все задачи в канал, закрываем канал и ждем, когда воркеры их обработают
-	close(taskCh)
+	close(jobCh)
 	wg.Wait()
-	if t.errors >= t.maxErrors {
-		return ErrErrorsLimitExceeded
+	if t.limitExceeded() {
+		cause = t.limitErr()
+	}
+	if cause != nil {
+		t.observer.OnAbort(cause)
 	}
-	return nil
+	return cause
 }
 
-func Run(tasks []Task, n, m int) error {
-	if n <= 0 {
-		return ErrErrorsNegativeWorkers
+func (t *TaskExecutor) RunContext(ctx context.Context, tasks []ContextTask) (Result, error) {
+	results := make([]TaskResult, len(tasks))
+	for i := range results {
+		results[i] = TaskResult{Index: i, Skipped: true}
+	}
+	cause := t.run(ctx, &sliceSource{tasks: tasks}, func(r TaskResult) {
+		// каждая задача пишет только в свою ячейку, поэтому блокировка не нужна
+		results[r.Index] = r
+	})
+	res := Result{Tasks: results}
+	return res, res.err(cause)
+}
+
+// RunSource выполняет задачи по мере их получения из src. Сохраняются только результаты
+// последних MaxSourceFailures упавших задач, остальные учитываются в RunError.Omitted,
+// поэтому память не зависит от общего количества задач и при IgnoreErrors или ErrorRate.
+// Все ошибки можно получить через Options.Observer.
+func (t *TaskExecutor) RunSource(ctx context.Context, src TaskSource) error {
+	mu := sync.Mutex{}
+	failed := make([]TaskResult, 0)
+	omitted := 0
+	cause := t.run(ctx, src, func(r TaskResult) {
+		if r.Err == nil {
+			return
+		}
+		mu.Lock()
+		if len(failed) == MaxSourceFailures {
+			failed = failed[1:]
+			omitted++
+		}
+		failed = append(failed, r)
+		mu.Unlock()
+	})
+	err := Result{Tasks: failed}.err(cause)
+	var runErr *RunError
+	if errors.As(err, &runErr) {
+		runErr.Omitted = omitted
 	}
-	if m < 0 {
-		return ErrErrorsNegativeErrors
+	return err
+}
+
+func (t *TaskExecutor) Run(tasks []Task) error {
+	ctxTasks := make([]ContextTask, 0, len(tasks))
+	for _, task := range tasks {
+		ctxTasks = append(ctxTasks, task.withContext())
 	}
-	executor := &TaskExecutor{
-		maxErrors: int32(m),
-		workers:   n,
+	_, err := t.RunContext(context.Background(), ctxTasks)
+	var runErr *RunError
+	if errors.As(err, &runErr) && runErr.Cause == nil {
+		// ошибки отдельных задач в пределах лимита не считаются ошибкой запуска
+		return nil
+	}
+	return err
+}
+
+// RunContext запускает задачи в opts.Workers горутинах и перестает выдавать новые задачи
+// при отмене ctx или после opts.MaxErrors ошибок. Ошибка, если она есть, имеет тип *RunError.
+func RunContext(ctx context.Context, tasks []ContextTask, opts Options) (Result, error) {
+	executor, err := NewTaskExecutor(opts)
+	if err != nil {
+		return Result{}, err
+	}
+	return executor.RunContext(ctx, tasks)
+}
+
+func RunSource(ctx context.Context, src TaskSource, opts Options) error {
+	executor, err := NewTaskExecutor(opts)
+	if err != nil {
+		return err
+	}
+	return executor.RunSource(ctx, src)
+}
+
+// RunChan выполняет задачи из канала, пока он не будет закрыт или не будет отменен ctx.
+func RunChan(ctx context.Context, tasks <-chan ContextTask, opts Options) error {
+	return RunSource(ctx, ChanSource(tasks), opts)
+}
+
+func Run(tasks []Task, n, m int) error {
+	executor, err := NewTaskExecutor(Options{
+		Workers:   n,
+		MaxErrors: m,
+	})
+	if err != nil {
+		return err
 	}
 	return executor.Run(tasks)
 }
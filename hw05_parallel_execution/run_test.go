@@ -1,6 +1,7 @@
 package hw05parallelexecution
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -163,3 +164,113 @@ func TestRun(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+type taskError struct {
+	index int
+}
+
+func (e *taskError) Error() string {
+	return fmt.Sprintf("task %d failed", e.index)
+}
+
+func TestRunContext(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("results per task", func(t *testing.T) {
+		errTask := errors.New("task error")
+		tasks := []ContextTask{
+			func(ctx context.Context) error { return nil },
+			func(ctx context.Context) error { return errTask },
+			func(ctx context.Context) error { return &taskError{index: 2} },
+			func(ctx context.Context) error { return nil },
+		}
+
+		res, err := RunContext(context.Background(), tasks, Options{Workers: 2, MaxErrors: 5})
+		require.Error(t, err)
+		require.Len(t, res.Tasks, len(tasks))
+		for i, task := range res.Tasks {
+			require.Equal(t, i, task.Index)
+			require.False(t, task.Skipped)
+		}
+		require.NoError(t, res.Tasks[0].Err)
+		require.ErrorIs(t, res.Tasks[1].Err, errTask)
+		require.Len(t, res.Failed(), 2)
+
+		var runErr *RunError
+		require.True(t, errors.As(err, &runErr))
+		require.NoError(t, runErr.Cause)
+		require.True(t, errors.Is(err, errTask))
+		require.False(t, errors.Is(err, ErrErrorsLimitExceeded))
+
+		var tErr *taskError
+		require.True(t, errors.As(err, &tErr))
+		require.Equal(t, 2, tErr.index)
+	})
+
+	t.Run("no errors", func(t *testing.T) {
+		tasks := []ContextTask{
+			func(ctx context.Context) error { return nil },
+			func(ctx context.Context) error { return nil },
+		}
+		res, err := RunContext(context.Background(), tasks, Options{Workers: 1, MaxErrors: 1})
+		require.NoError(t, err)
+		require.Len(t, res.Failed(), 0)
+	})
+
+	t.Run("errors limit", func(t *testing.T) {
+		errTask := errors.New("task error")
+		tasks := make([]ContextTask, 0, 10)
+		for i := 0; i < 10; i++ {
+			tasks = append(tasks, func(ctx context.Context) error { return errTask })
+		}
+		res, err := RunContext(context.Background(), tasks, Options{Workers: 1, MaxErrors: 3})
+		require.True(t, errors.Is(err, ErrErrorsLimitExceeded), "actual err - %v", err)
+		require.True(t, errors.Is(err, errTask), "actual err - %v", err)
+		require.Len(t, res.Failed(), 3)
+		require.True(t, res.Tasks[len(tasks)-1].Skipped)
+	})
+
+	t.Run("cancel stops dispatching", func(t *testing.T) {
+		tasksCount := 50
+		workersCount := 5
+		tasks := make([]ContextTask, 0, tasksCount)
+		var runTasksCount int32
+		for i := 0; i < tasksCount; i++ {
+			tasks = append(tasks, func(ctx context.Context) error {
+				atomic.AddInt32(&runTasksCount, 1)
+				<-ctx.Done()
+				return ctx.Err()
+			})
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			// отменяем, когда все воркеры заняты
+			for atomic.LoadInt32(&runTasksCount) < int32(workersCount) {
+				time.Sleep(time.Millisecond)
+			}
+			cancel()
+		}()
+
+		res, err := RunContext(ctx, tasks, Options{Workers: workersCount, MaxErrors: tasksCount})
+		require.True(t, errors.Is(err, context.Canceled), "actual err - %v", err)
+		var runErr *RunError
+		require.True(t, errors.As(err, &runErr))
+		require.True(t, errors.Is(runErr.Cause, context.Canceled))
+		require.Equal(t, int32(workersCount), atomic.LoadInt32(&runTasksCount))
+		require.Len(t, res.Failed(), workersCount)
+		require.True(t, res.Tasks[tasksCount-1].Skipped)
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		tasks := []ContextTask{
+			func(ctx context.Context) error {
+				time.Sleep(20 * time.Millisecond)
+				return nil
+			},
+		}
+		res, err := RunContext(context.Background(), tasks, Options{Workers: 1, MaxErrors: 1})
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, int64(res.Tasks[0].Duration), int64(20*time.Millisecond))
+	})
+}
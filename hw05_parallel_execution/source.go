@@ -0,0 +1,45 @@
+package hw05parallelexecution
+
+import "context"
+
+// TaskSource отдает задачи по одной. Next возвращает false, когда задачи закончились
+// или ctx отменен; ctx отменяется также при превышении лимита ошибок.
+type TaskSource interface {
+	Next(ctx context.Context) (ContextTask, bool)
+}
+
+// TaskSourceFunc позволяет использовать функцию-итератор как TaskSource.
+type TaskSourceFunc func(ctx context.Context) (ContextTask, bool)
+
+func (f TaskSourceFunc) Next(ctx context.Context) (ContextTask, bool) {
+	return f(ctx)
+}
+
+type chanSource <-chan ContextTask
+
+func ChanSource(tasks <-chan ContextTask) TaskSource {
+	return chanSource(tasks)
+}
+
+func (s chanSource) Next(ctx context.Context) (ContextTask, bool) {
+	select {
+	case <-ctx.Done():
+		return nil, false
+	case task, ok := <-s:
+		return task, ok
+	}
+}
+
+type sliceSource struct {
+	tasks []ContextTask
+	next  int
+}
+
+func (s *sliceSource) Next(context.Context) (ContextTask, bool) {
+	if s.next >= len(s.tasks) {
+		return nil, false
+	}
+	task := s.tasks[s.next]
+	s.next++
+	return task, true
+}
@@ -0,0 +1,118 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestRunChan(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("lazy producer", func(t *testing.T) {
+		tasksCount := 10000
+		var runTasksCount int32
+		tasks := make(chan ContextTask)
+		go func() {
+			defer close(tasks)
+			for i := 0; i < tasksCount; i++ {
+				tasks <- func(ctx context.Context) error {
+					atomic.AddInt32(&runTasksCount, 1)
+					return nil
+				}
+			}
+		}()
+
+		err := RunChan(context.Background(), tasks, Options{Workers: 10, MaxErrors: 1})
+		require.NoError(t, err)
+		require.Equal(t, int32(tasksCount), atomic.LoadInt32(&runTasksCount))
+	})
+
+	t.Run("errors limit with producer that never closes", func(t *testing.T) {
+		errTask := errors.New("task error")
+		workersCount := 5
+		maxErrorsCount := 10
+		var runTasksCount int32
+		tasks := make(chan ContextTask)
+		done := make(chan struct{})
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case tasks <- func(ctx context.Context) error {
+					atomic.AddInt32(&runTasksCount, 1)
+					return errTask
+				}:
+				}
+			}
+		}()
+
+		err := RunChan(context.Background(), tasks, Options{Workers: workersCount, MaxErrors: maxErrorsCount})
+		close(done)
+		require.True(t, errors.Is(err, ErrErrorsLimitExceeded), "actual err - %v", err)
+		require.True(t, errors.Is(err, errTask), "actual err - %v", err)
+		require.LessOrEqual(t, atomic.LoadInt32(&runTasksCount), int32(workersCount+maxErrorsCount))
+	})
+
+	t.Run("waiting source is cancelled by context", func(t *testing.T) {
+		tasks := make(chan ContextTask)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := RunChan(ctx, tasks, Options{Workers: 2, MaxErrors: 1})
+		require.True(t, errors.Is(err, context.DeadlineExceeded), "actual err - %v", err)
+	})
+}
+
+func TestRunSource(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	tasksCount := 100
+	var produced, runTasksCount int32
+	src := TaskSourceFunc(func(ctx context.Context) (ContextTask, bool) {
+		if atomic.LoadInt32(&produced) == int32(tasksCount) {
+			return nil, false
+		}
+		atomic.AddInt32(&produced, 1)
+		return func(ctx context.Context) error {
+			atomic.AddInt32(&runTasksCount, 1)
+			return nil
+		}, true
+	})
+
+	err := RunSource(context.Background(), src, Options{Workers: 4, MaxErrors: 1})
+	require.NoError(t, err)
+	require.Equal(t, int32(tasksCount), atomic.LoadInt32(&runTasksCount))
+
+	err = RunSource(context.Background(), src, Options{Workers: 0, MaxErrors: 1})
+	require.True(t, errors.Is(err, ErrErrorsNegativeWorkers), "actual err - %v", err)
+
+	t.Run("failures are capped", func(t *testing.T) {
+		errTask := errors.New("task error")
+		failuresCount := MaxSourceFailures*3 + 7
+		var produced int32
+		src := TaskSourceFunc(func(ctx context.Context) (ContextTask, bool) {
+			if atomic.AddInt32(&produced, 1) > int32(failuresCount) {
+				return nil, false
+			}
+			return func(ctx context.Context) error {
+				return errTask
+			}, true
+		})
+
+		err := RunSource(context.Background(), src, Options{Workers: 4, IgnoreErrors: true})
+		var runErr *RunError
+		require.True(t, errors.As(err, &runErr))
+		require.NoError(t, runErr.Cause)
+		require.Len(t, runErr.Tasks, MaxSourceFailures)
+		require.Equal(t, failuresCount-MaxSourceFailures, runErr.Omitted)
+		require.True(t, errors.Is(err, errTask))
+		require.Contains(t, err.Error(), "and 207 more")
+	})
+}
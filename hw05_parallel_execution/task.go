@@ -0,0 +1,71 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"fmt"
+)
+
+// runTask выполняет задачу с учетом ограничения скорости, таймаута и политики повторов.
+func (t *TaskExecutor) runTask(ctx context.Context, j job) TaskResult {
+	if err := t.wait(ctx); err != nil {
+		// до первой попытки дело не дошло, задача считается незапущенной
+		t.observer.OnSkip(j.index)
+		return TaskResult{Index: j.index, Skipped: true}
+	}
+	start := t.clock.Now()
+	res := TaskResult{Index: j.index}
+	t.observer.OnStart(j.index)
+	defer func() {
+		t.observer.OnFinish(j.index, res.Err, res.Duration)
+	}()
+	for {
+		res.Attempts++
+		res.Err = t.runAttempt(ctx, j.task)
+		if res.Err == nil || !t.retry.retryable(res.Attempts, res.Err) {
+			break
+		}
+		t.observer.OnRetry(j.index, res.Attempts, res.Err)
+		select {
+		case <-ctx.Done():
+			res.Duration = t.clock.Since(start)
+			return res
+		case <-t.clock.After(t.retry.backoff(res.Attempts)):
+		}
+		if err := t.wait(ctx); err != nil {
+			break
+		}
+	}
+	res.Duration = t.clock.Since(start)
+	return res
+}
+
+func (t *TaskExecutor) wait(ctx context.Context) error {
+	if t.limiter == nil {
+		return nil
+	}
+	return t.limiter.Wait(ctx)
+}
+
+func (t *TaskExecutor) runAttempt(ctx context.Context, task ContextTask) error {
+	if t.timeout == 0 {
+		return safeCall(ctx, task)
+	}
+	attemptCtx, cancel := t.clock.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	// буфер, чтобы брошенная по таймауту задача могла завершиться без блокировки
+	done := make(chan error, 1)
+	go func() {
+		done <- safeCall(attemptCtx, task)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-attemptCtx.Done():
+		if ctx.Err() != nil {
+			// отмена всего запуска - задачу не ждем, она допишет результат в буфер done
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %s", ErrTaskTimeout, t.timeout)
+	}
+}
@@ -0,0 +1,165 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var ErrNotItem = errors.New("pipeline output is not an Item")
+
+// Item - значение с порядковым номером во входном потоке. Номера должны возрастать.
+type Item struct {
+	Seq   uint64
+	Value interface{}
+}
+
+// Checkpointer хранит номер последнего значения, полностью прошедшего пайплайн.
+type Checkpointer interface {
+	// Load возвращает сохраненный номер, ok = false, если чекпоинта еще нет
+	Load() (seq uint64, ok bool, err error)
+	Save(seq uint64) error
+}
+
+// FileCheckpointer хранит номер в текстовом файле и обновляет его атомарно через rename.
+type FileCheckpointer struct {
+	path string
+}
+
+func NewFileCheckpointer(path string) *FileCheckpointer {
+	return &FileCheckpointer{path: path}
+}
+
+func (c *FileCheckpointer) Load() (uint64, bool, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	seq, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("checkpoint %s: %w", c.path, err)
+	}
+	return seq, true, nil
+}
+
+func (c *FileCheckpointer) Save(seq uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(strconv.FormatUint(seq, 10) + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// MapItem строит стейдж, применяющий f к значению Item и сохраняющий его номер.
+func MapItem(f func(v interface{}) interface{}) Stage {
+	return Untyped(Map(func(item Item) Item {
+		return Item{Seq: item.Seq, Value: f(item.Value)}
+	}))
+}
+
+// ExecuteCheckpointed запускает пайплайн над Item и после выдачи каждого Item сохраняет его
+// номер в cp. При повторном запуске значения с номером не больше сохраненного пропускаются.
+// Стейджи должны передавать Item дальше (например, через MapItem) и сохранять порядок значений,
+// иначе после перезапуска могут быть пропущены необработанные значения.
+// Канал ошибок получает ошибку загрузки или сохранения чекпоинта либо ErrNotItem.
+func ExecuteCheckpointed(ctx context.Context, in <-chan Item, done In, cp Checkpointer,
+	stages ...Stage,
+) (Out, <-chan error) {
+	out := make(Bi)
+	errc := make(chan error, 1)
+
+	last, resume, err := cp.Load()
+	if err != nil {
+		close(out)
+		errc <- err
+		close(errc)
+		return out, errc
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	pending := make(Bi)
+	go func() {
+		defer close(pending)
+		for {
+			select {
+			case <-done:
+				return
+			case <-runCtx.Done():
+				return
+			case item, ok := <-in:
+				if !ok {
+					return
+				}
+				if resume && item.Seq <= last {
+					// уже обработано в прошлом запуске
+					continue
+				}
+				select {
+				case <-done:
+					return
+				case <-runCtx.Done():
+					return
+				case pending <- item:
+				}
+			}
+		}
+	}()
+
+	go func() {
+		defer close(errc)
+		defer close(out)
+		defer cancel()
+		var failed error
+		seq, saved := last, resume
+		// при остановке перестаем подавать значения и дочитываем выход, чтобы стейджи завершились
+		for v := range ExecutePipeline(pending, done, stages...) {
+			if failed != nil {
+				continue
+			}
+			item, ok := v.(Item)
+			if !ok {
+				failed = fmt.Errorf("%w: %T", ErrNotItem, v)
+				cancel()
+				continue
+			}
+			if runCtx.Err() != nil {
+				continue
+			}
+			select {
+			case <-done:
+				continue
+			case <-runCtx.Done():
+				continue
+			case out <- item:
+			}
+			if saved && item.Seq <= seq {
+				continue
+			}
+			if err := cp.Save(item.Seq); err != nil {
+				failed = err
+				cancel()
+				continue
+			}
+			seq, saved = item.Seq, true
+		}
+		if failed != nil {
+			errc <- failed
+		}
+	}()
+	return out, errc
+}
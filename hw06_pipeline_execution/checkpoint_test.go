@@ -0,0 +1,134 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+type memCheckpointer struct {
+	seq   uint64
+	ok    bool
+	saves int
+	err   error
+}
+
+func (c *memCheckpointer) Load() (uint64, bool, error) {
+	return c.seq, c.ok, nil
+}
+
+func (c *memCheckpointer) Save(seq uint64) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.seq, c.ok = seq, true
+	c.saves++
+	return nil
+}
+
+func produceItems(values ...interface{}) <-chan Item {
+	// буфер на все значения, чтобы источник не зависал после остановки пайплайна
+	in := make(chan Item, len(values))
+	for i, v := range values {
+		in <- Item{Seq: uint64(i + 1), Value: v}
+	}
+	close(in)
+	return in
+}
+
+func TestFileCheckpointer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	cp := NewFileCheckpointer(path)
+
+	_, ok, err := cp.Load()
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, cp.Save(42))
+	require.NoError(t, cp.Save(43))
+	seq, ok, err := cp.Load()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(43), seq)
+
+	// временные файлы не остаются
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte("garbage"), 0o600))
+	_, _, err = cp.Load()
+	require.Error(t, err)
+}
+
+func TestExecuteCheckpointed(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	double := MapItem(func(v interface{}) interface{} { return v.(int) * 2 })
+
+	t.Run("resume after restart", func(t *testing.T) {
+		cp := NewFileCheckpointer(filepath.Join(t.TempDir(), "checkpoint"))
+		ctx, cancel := context.WithCancel(context.Background())
+		out, errc := ExecuteCheckpointed(ctx, produceItems(1, 2, 3, 4, 5), nil, cp, double)
+		// обрабатываем два значения и "падаем"
+		require.Equal(t, Item{Seq: 1, Value: 2}, <-out)
+		require.Equal(t, Item{Seq: 2, Value: 4}, <-out)
+		cancel()
+		<-drain(out)
+		require.NoError(t, <-errc)
+
+		seq, ok, err := cp.Load()
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.GreaterOrEqual(t, seq, uint64(2))
+
+		processed := 0
+		count := MapItem(func(v interface{}) interface{} {
+			processed++
+			return v
+		})
+		out, errc = ExecuteCheckpointed(context.Background(), produceItems(1, 2, 3, 4, 5), nil, cp, count, double)
+		result := collect(out)
+		require.NoError(t, <-errc)
+		// после отмены часть значений могла успеть пройти и попасть в чекпоинт
+		require.Equal(t, 5-int(seq), processed)
+		require.Len(t, result, processed)
+		for i, item := range result {
+			require.Equal(t, Item{Seq: seq + uint64(i) + 1, Value: int(seq+uint64(i)+1) * 2}, item)
+		}
+
+		seq, _, err = cp.Load()
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), seq)
+	})
+
+	t.Run("completed run skips everything", func(t *testing.T) {
+		cp := &memCheckpointer{seq: 3, ok: true}
+		out, errc := ExecuteCheckpointed(context.Background(), produceItems(1, 2, 3), nil, cp, double)
+		require.Empty(t, collect(out))
+		require.NoError(t, <-errc)
+		require.Equal(t, 0, cp.saves)
+	})
+
+	t.Run("stage output is not an item", func(t *testing.T) {
+		cp := &memCheckpointer{}
+		unwrap := Untyped(Map(func(item Item) interface{} { return item.Value }))
+		out, errc := ExecuteCheckpointed(context.Background(), produceItems(1, 2, 3), nil, cp, unwrap)
+		require.Empty(t, collect(out))
+		require.ErrorIs(t, <-errc, ErrNotItem)
+		require.False(t, cp.ok)
+	})
+
+	t.Run("save error aborts", func(t *testing.T) {
+		errSave := errors.New("disk full")
+		cp := &memCheckpointer{err: errSave}
+		out, errc := ExecuteCheckpointed(context.Background(), produceItems(1, 2, 3), nil, cp, double)
+		require.Len(t, collect(out), 1)
+		require.ErrorIs(t, <-errc, errSave)
+	})
+}
@@ -0,0 +1,288 @@
+package hw06pipelineexecution
+
+import "time"
+
+func send(done In, out Bi, v interface{}) bool {
+	select {
+	case <-done:
+		return false
+	case out <- v:
+		return true
+	}
+}
+
+// Batch группирует значения в []interface{} по size штук. Неполная пачка отправляется,
+// если с момента получения ее первого значения прошло interval (0 - не ждать по времени)
+// или вход закрылся. size <= 0 считается равным 1, отрицательный interval - равным 0.
+func Batch(done In, size int, interval time.Duration) Stage {
+	if size <= 0 {
+		size = 1
+	}
+	if interval < 0 {
+		interval = 0
+	}
+	return func(in In) Out {
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			batch := make([]interface{}, 0, size)
+			var timeout <-chan time.Time
+			var timer *time.Timer
+			flush := func() bool {
+				if timer != nil {
+					timer.Stop()
+					timer, timeout = nil, nil
+				}
+				if len(batch) == 0 {
+					return true
+				}
+				ok := send(done, out, batch)
+				batch = make([]interface{}, 0, size)
+				return ok
+			}
+			for {
+				select {
+				case <-done:
+					return
+				case <-timeout:
+					if !flush() {
+						return
+					}
+				case v, ok := <-in:
+					if !ok {
+						flush()
+						return
+					}
+					batch = append(batch, v)
+					if len(batch) == 1 && interval > 0 {
+						timer = time.NewTimer(interval)
+						timeout = timer.C
+					}
+					if len(batch) >= size && !flush() {
+						return
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// TumblingWindow отправляет значения, полученные за каждый интервал d, одним []interface{}.
+// Пустые окна пропускаются. При d <= 0 каждое значение отправляется отдельным окном.
+func TumblingWindow(done In, d time.Duration) Stage {
+	if d <= 0 {
+		return Batch(done, 1, 0)
+	}
+	return func(in In) Out {
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			ticker := time.NewTicker(d)
+			defer ticker.Stop()
+			window := make([]interface{}, 0)
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if len(window) == 0 {
+						continue
+					}
+					if !send(done, out, window) {
+						return
+					}
+					window = make([]interface{}, 0)
+				case v, ok := <-in:
+					if !ok {
+						if len(window) > 0 {
+							send(done, out, window)
+						}
+						return
+					}
+					window = append(window, v)
+				}
+			}
+		}()
+		return out
+	}
+}
+
+type timedValue struct {
+	at time.Time
+	v  interface{}
+}
+
+// SlidingWindow каждые slide отправляет значения, полученные за последние size.
+// Окна перекрываются, поэтому одно значение может попасть в несколько окон.
+// Если size или slide <= 0, он берется равным другому (окна не перекрываются),
+// если оба <= 0 - стейдж работает как TumblingWindow(done, 0).
+func SlidingWindow(done In, size, slide time.Duration) Stage {
+	if size <= 0 {
+		size = slide
+	}
+	if slide <= 0 {
+		slide = size
+	}
+	if slide <= 0 {
+		return TumblingWindow(done, 0)
+	}
+	return func(in In) Out {
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			ticker := time.NewTicker(slide)
+			defer ticker.Stop()
+			values := make([]timedValue, 0)
+			fresh := false
+			emit := func(now time.Time) bool {
+				i := 0
+				for i < len(values) && now.Sub(values[i].at) > size {
+					i++
+				}
+				values = values[i:]
+				if len(values) == 0 {
+					return true
+				}
+				window := make([]interface{}, 0, len(values))
+				for _, tv := range values {
+					window = append(window, tv.v)
+				}
+				fresh = false
+				return send(done, out, window)
+			}
+			for {
+				select {
+				case <-done:
+					return
+				case now := <-ticker.C:
+					if !emit(now) {
+						return
+					}
+				case v, ok := <-in:
+					if !ok {
+						// последние значения еще не попадали ни в одно окно
+						if fresh {
+							emit(time.Now())
+						}
+						return
+					}
+					values = append(values, timedValue{at: time.Now(), v: v})
+					fresh = true
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// Throttle пропускает не больше perSecond значений в секунду, равномерно распределяя их во времени.
+// perSecond <= 0 - без ограничения.
+func Throttle(done In, perSecond int) Stage {
+	var interval time.Duration
+	if perSecond > 0 {
+		interval = time.Second / time.Duration(perSecond)
+	}
+	return func(in In) Out {
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			var next time.Time
+			for {
+				select {
+				case <-done:
+					return
+				case v, ok := <-in:
+					if !ok {
+						return
+					}
+					if wait := time.Until(next); wait > 0 {
+						timer := time.NewTimer(wait)
+						select {
+						case <-done:
+							timer.Stop()
+							return
+						case <-timer.C:
+						}
+					}
+					next = time.Now().Add(interval)
+					if !send(done, out, v) {
+						return
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// Debounce отправляет значение, только если за ним в течение d не пришло следующее.
+// Из серии частых значений остается последнее.
+func Debounce(done In, d time.Duration) Stage {
+	return func(in In) Out {
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			var pending interface{}
+			var timeout <-chan time.Time
+			var timer *time.Timer
+			for {
+				select {
+				case <-done:
+					return
+				case <-timeout:
+					timeout = nil
+					if !send(done, out, pending) {
+						return
+					}
+				case v, ok := <-in:
+					if !ok {
+						if timeout != nil {
+							timer.Stop()
+							send(done, out, pending)
+						}
+						return
+					}
+					if timer != nil {
+						timer.Stop()
+					}
+					pending = v
+					timer = time.NewTimer(d)
+					timeout = timer.C
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// Dedupe пропускает только первое значение для каждого ключа. Ключи хранятся до конца
+// работы стейджа и должны быть сравнимыми.
+func Dedupe(done In, key func(v interface{}) interface{}) Stage {
+	return func(in In) Out {
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			seen := make(map[interface{}]struct{})
+			for {
+				select {
+				case <-done:
+					return
+				case v, ok := <-in:
+					if !ok {
+						return
+					}
+					k := key(v)
+					if _, ok := seen[k]; ok {
+						continue
+					}
+					seen[k] = struct{}{}
+					if !send(done, out, v) {
+						return
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
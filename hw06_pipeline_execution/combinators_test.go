@@ -0,0 +1,180 @@
+package hw06pipelineexecution
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// produceEvery отправляет значения с паузой перед каждым.
+func produceEvery(pause time.Duration, data ...interface{}) In {
+	in := make(Bi)
+	go func() {
+		defer close(in)
+		for _, v := range data {
+			time.Sleep(pause)
+			in <- v
+		}
+	}()
+	return in
+}
+
+func collect(out Out) []interface{} {
+	result := make([]interface{}, 0)
+	for v := range out {
+		result = append(result, v)
+	}
+	return result
+}
+
+func TestBatch(t *testing.T) {
+	t.Run("by count", func(t *testing.T) {
+		result := collect(ExecutePipeline(produce(1, 2, 3, 4, 5), nil, Batch(nil, 2, 0)))
+		require.Equal(t, []interface{}{
+			[]interface{}{1, 2},
+			[]interface{}{3, 4},
+			[]interface{}{5},
+		}, result)
+	})
+
+	t.Run("by time", func(t *testing.T) {
+		in := make(Bi)
+		out := ExecutePipeline(in, nil, Batch(nil, 10, 30*time.Millisecond))
+		in <- 1
+		in <- 2
+		start := time.Now()
+		require.Equal(t, []interface{}{1, 2}, <-out)
+		require.GreaterOrEqual(t, int64(time.Since(start)), int64(20*time.Millisecond))
+		in <- 3
+		close(in)
+		require.Equal(t, []interface{}{3}, <-out)
+		_, ok := <-out
+		require.False(t, ok)
+	})
+
+	t.Run("invalid arguments are normalized", func(t *testing.T) {
+		for _, size := range []int{0, -1} {
+			result := collect(ExecutePipeline(produce(1, 2), nil, Batch(nil, size, -time.Second)))
+			require.Equal(t, []interface{}{[]interface{}{1}, []interface{}{2}}, result)
+		}
+	})
+}
+
+func TestWindowInvalidDuration(t *testing.T) {
+	single := []interface{}{[]interface{}{1}, []interface{}{2}}
+	require.Equal(t, single, collect(ExecutePipeline(produce(1, 2), nil, TumblingWindow(nil, 0))))
+	require.Equal(t, single, collect(ExecutePipeline(produce(1, 2), nil, TumblingWindow(nil, -time.Second))))
+	require.Equal(t, single, collect(ExecutePipeline(produce(1, 2), nil, SlidingWindow(nil, 0, 0))))
+
+	// без slide окна не перекрываются
+	result := collect(ExecutePipeline(produce(1, 2), nil, SlidingWindow(nil, 20*time.Millisecond, 0)))
+	require.Equal(t, []interface{}{[]interface{}{1, 2}}, result)
+}
+
+func TestTumblingWindow(t *testing.T) {
+	in := make(Bi)
+	out := ExecutePipeline(in, nil, TumblingWindow(nil, 50*time.Millisecond))
+	in <- 1
+	in <- 2
+	require.Equal(t, []interface{}{1, 2}, <-out)
+	in <- 3
+	close(in)
+	require.Equal(t, []interface{}{3}, <-out)
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	result := collect(ExecutePipeline(produceEvery(40*time.Millisecond, 1, 2, 3, 4), nil,
+		SlidingWindow(nil, 100*time.Millisecond, 50*time.Millisecond)))
+	require.NotEmpty(t, result)
+
+	// окна перекрываются, но каждое значение попадает хотя бы в одно окно
+	seen := make(map[interface{}]int)
+	for _, w := range result {
+		window := w.([]interface{})
+		require.LessOrEqual(t, len(window), 3)
+		for _, v := range window {
+			seen[v]++
+		}
+	}
+	require.Len(t, seen, 4)
+	overlap := false
+	for _, n := range seen {
+		overlap = overlap || n > 1
+	}
+	require.True(t, overlap)
+}
+
+func TestThrottle(t *testing.T) {
+	start := time.Now()
+	result := collect(ExecutePipeline(produce(1, 2, 3, 4, 5), nil, Throttle(nil, 50)))
+	require.Equal(t, []interface{}{1, 2, 3, 4, 5}, result)
+	// 5 значений при 50 в секунду - не быстрее 4 интервалов по 20мс
+	require.GreaterOrEqual(t, int64(time.Since(start)), int64(80*time.Millisecond))
+
+	result = collect(ExecutePipeline(produce(1, 2), nil, Throttle(nil, 0)))
+	require.Equal(t, []interface{}{1, 2}, result)
+}
+
+func TestDebounce(t *testing.T) {
+	in := make(Bi)
+	out := ExecutePipeline(in, nil, Debounce(nil, 30*time.Millisecond))
+	go func() {
+		defer close(in)
+		in <- "a"
+		in <- "ab"
+		in <- "abc"
+		time.Sleep(100 * time.Millisecond)
+		in <- "x"
+		in <- "xy"
+	}()
+	require.Equal(t, []interface{}{"abc", "xy"}, collect(out))
+}
+
+func TestDedupe(t *testing.T) {
+	key := func(v interface{}) interface{} {
+		return strings.ToLower(v.(string))
+	}
+	result := collect(ExecutePipeline(produce("a", "B", "A", "c", "b"), nil, Dedupe(nil, key)))
+	require.Equal(t, []interface{}{"a", "B", "c"}, result)
+}
+
+func TestCombinatorsDone(t *testing.T) {
+	builders := map[string]func(done In) Stage{
+		"batch":    func(done In) Stage { return Batch(done, 100, time.Hour) },
+		"tumbling": func(done In) Stage { return TumblingWindow(done, time.Hour) },
+		"sliding":  func(done In) Stage { return SlidingWindow(done, time.Hour, time.Hour) },
+		"throttle": func(done In) Stage { return Throttle(done, 1) },
+		"debounce": func(done In) Stage { return Debounce(done, time.Hour) },
+		"dedupe":   func(done In) Stage { return Dedupe(done, func(v interface{}) interface{} { return v }) },
+	}
+	for name, build := range builders {
+		build := build
+		t.Run(name, func(t *testing.T) {
+			done := make(Bi)
+			in := make(Bi)
+			out := build(done)(in)
+			in <- 1
+			close(done)
+
+			select {
+			case <-time.After(time.Second):
+				t.Fatal("stage is not stopped by done")
+			case <-drain(out):
+			}
+		})
+	}
+}
+
+func drain(out Out) <-chan struct{} {
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for range out {
+		}
+	}()
+	return finished
+}
@@ -0,0 +1,254 @@
+package hw06pipelineexecution
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnknownStage      = errors.New("unknown stage type")
+	ErrFactoryExists     = errors.New("stage factory already registered")
+	ErrInvalidDefinition = errors.New("invalid pipeline definition")
+	ErrInvalidParam      = errors.New("invalid stage parameter")
+)
+
+// StageFactory строит стейдж по параметрам из определения пайплайна.
+type StageFactory func(done In, params Params) (Stage, error)
+
+// Definition - описание пайплайна в YAML или JSON:
+//
+//	name: etl
+//	stages:
+//	  - name: group
+//	    type: batch
+//	    params: {size: 100, interval: 1s}
+//	    parallelism: 1
+//	    buffer: 10
+type Definition struct {
+	Name   string            `json:"name" yaml:"name"`
+	Stages []StageDefinition `json:"stages" yaml:"stages"`
+}
+
+type StageDefinition struct {
+	Name        string `json:"name" yaml:"name"`
+	Type        string `json:"type" yaml:"type"`
+	Params      Params `json:"params" yaml:"params"`
+	Parallelism int    `json:"parallelism" yaml:"parallelism"`
+	Buffer      int    `json:"buffer" yaml:"buffer"`
+}
+
+// Params - параметры стейджа. Числа из JSON приходят как float64, из YAML - как int,
+// длительности задаются строкой в формате time.ParseDuration.
+type Params map[string]interface{}
+
+func (p Params) Int(name string, def int) (int, error) {
+	v, ok := p[name]
+	if !ok {
+		return def, nil
+	}
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		if n == math.Trunc(n) {
+			return int(n), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s must be an integer, got %v", ErrInvalidParam, name, v)
+}
+
+func (p Params) Duration(name string, def time.Duration) (time.Duration, error) {
+	v, ok := p[name]
+	if !ok {
+		return def, nil
+	}
+	if s, ok := v.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s must be a duration like \"100ms\", got %v", ErrInvalidParam, name, v)
+}
+
+func (p Params) String(name string, def string) (string, error) {
+	v, ok := p[name]
+	if !ok {
+		return def, nil
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("%w: %s must be a string, got %v", ErrInvalidParam, name, v)
+}
+
+// Registry хранит фабрики стейджей по имени типа.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]StageFactory
+}
+
+// NewRegistry создает реестр со встроенными типами: batch, tumbling_window, sliding_window,
+// throttle и debounce.
+func NewRegistry() *Registry {
+	r := &Registry{
+		factories: make(map[string]StageFactory),
+	}
+	builtins := map[string]StageFactory{
+		"batch": func(done In, params Params) (Stage, error) {
+			size, err := params.Int("size", 1)
+			if err != nil {
+				return nil, err
+			}
+			if size <= 0 {
+				return nil, fmt.Errorf("%w: size must be > 0", ErrInvalidParam)
+			}
+			interval, err := params.Duration("interval", 0)
+			if err != nil {
+				return nil, err
+			}
+			return Batch(done, size, interval), nil
+		},
+		"tumbling_window": func(done In, params Params) (Stage, error) {
+			d, err := positiveDuration(params, "size")
+			if err != nil {
+				return nil, err
+			}
+			return TumblingWindow(done, d), nil
+		},
+		"sliding_window": func(done In, params Params) (Stage, error) {
+			size, err := positiveDuration(params, "size")
+			if err != nil {
+				return nil, err
+			}
+			slide, err := positiveDuration(params, "slide")
+			if err != nil {
+				return nil, err
+			}
+			return SlidingWindow(done, size, slide), nil
+		},
+		"throttle": func(done In, params Params) (Stage, error) {
+			perSecond, err := params.Int("per_second", 0)
+			if err != nil {
+				return nil, err
+			}
+			return Throttle(done, perSecond), nil
+		},
+		"debounce": func(done In, params Params) (Stage, error) {
+			d, err := positiveDuration(params, "delay")
+			if err != nil {
+				return nil, err
+			}
+			return Debounce(done, d), nil
+		},
+	}
+	for name, factory := range builtins {
+		r.factories[name] = factory
+	}
+	return r
+}
+
+func positiveDuration(params Params, name string) (time.Duration, error) {
+	d, err := params.Duration(name, 0)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%w: %s must be > 0", ErrInvalidParam, name)
+	}
+	return d, nil
+}
+
+func (r *Registry) Register(name string, factory StageFactory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("%w: %s", ErrFactoryExists, name)
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+// Build проверяет определение и собирает из него Pipeline. Стейджи с parallelism > 1
+// запускаются через FanOut, порядок их значений не сохраняется.
+func (r *Registry) Build(def Definition, done In) (*Pipeline, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p := NewPipeline()
+	names := make(map[string]struct{}, len(def.Stages))
+	for i, s := range def.Stages {
+		name := s.Name
+		if name == "" {
+			name = fmt.Sprintf("%d:%s", i, s.Type)
+		}
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("%w: stage %q is defined twice", ErrInvalidDefinition, name)
+		}
+		names[name] = struct{}{}
+		if s.Parallelism < 0 || s.Buffer < 0 {
+			return nil, fmt.Errorf("%w: stage %q: parallelism and buffer must be >= 0", ErrInvalidDefinition, name)
+		}
+		factory, ok := r.factories[s.Type]
+		if !ok {
+			return nil, fmt.Errorf("%w: stage %q: %s", ErrUnknownStage, name, s.Type)
+		}
+		params := s.Params
+		if params == nil {
+			params = Params{}
+		}
+		stage, err := factory(done, params)
+		if err != nil {
+			return nil, fmt.Errorf("stage %q: %w", name, err)
+		}
+		p.Add(name, FanOut(done, s.Parallelism, stage), s.Buffer)
+	}
+	return p, nil
+}
+
+func ParseJSON(data []byte) (Definition, error) {
+	def := Definition{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&def); err != nil {
+		return Definition{}, fmt.Errorf("%w: %s", ErrInvalidDefinition, err)
+	}
+	return def, nil
+}
+
+func ParseYAML(data []byte) (Definition, error) {
+	def := Definition{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&def); err != nil {
+		return Definition{}, fmt.Errorf("%w: %s", ErrInvalidDefinition, err)
+	}
+	return def, nil
+}
+
+// LoadDefinition читает определение из файла, формат выбирается по расширению (.json, .yaml, .yml).
+func LoadDefinition(path string) (Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Definition{}, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ParseJSON(data)
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	default:
+		return Definition{}, fmt.Errorf("%w: unsupported file extension %q", ErrInvalidDefinition, filepath.Ext(path))
+	}
+}
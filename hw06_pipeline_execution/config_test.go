@@ -0,0 +1,160 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	err := r.Register("multiply", func(done In, params Params) (Stage, error) {
+		factor, err := params.Int("factor", 1)
+		if err != nil {
+			return nil, err
+		}
+		return Parallel(done, func(v interface{}) interface{} {
+			return v.(int) * factor
+		}, ParallelOptions{}), nil
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func TestConfigPipeline(t *testing.T) {
+	yamlDef := `
+name: etl
+stages:
+  - name: double
+    type: multiply
+    params:
+      factor: 2
+    parallelism: 3
+    buffer: 4
+  - name: group
+    type: batch
+    params:
+      size: 10
+      interval: 1s
+`
+	jsonDef := `{
+  "name": "etl",
+  "stages": [
+    {"name": "double", "type": "multiply", "params": {"factor": 2}, "parallelism": 3, "buffer": 4},
+    {"name": "group", "type": "batch", "params": {"size": 10, "interval": "1s"}}
+  ]
+}`
+
+	yamlParsed, err := ParseYAML([]byte(yamlDef))
+	require.NoError(t, err)
+	jsonParsed, err := ParseJSON([]byte(jsonDef))
+	require.NoError(t, err)
+
+	for name, def := range map[string]Definition{"yaml": yamlParsed, "json": jsonParsed} {
+		def := def
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, "etl", def.Name)
+			require.Len(t, def.Stages, 2)
+
+			p, err := testRegistry(t).Build(def, nil)
+			require.NoError(t, err)
+			result := collect(p.Execute(context.Background(), produce(1, 2, 3, 4, 5), nil))
+			require.Len(t, result, 1)
+
+			batch := make([]int, 0)
+			for _, v := range result[0].([]interface{}) {
+				batch = append(batch, v.(int))
+			}
+			sort.Ints(batch)
+			require.Equal(t, []int{2, 4, 6, 8, 10}, batch)
+
+			stats := p.Stats()
+			require.Equal(t, "double", stats[0].Name)
+			require.Equal(t, 4, stats[0].Buffer)
+			require.Equal(t, int64(5), stats[1].In)
+		})
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		def  string
+		err  error
+	}{
+		{
+			name: "unknown type",
+			def:  "stages: [{name: a, type: nope}]",
+			err:  ErrUnknownStage,
+		},
+		{
+			name: "invalid param type",
+			def:  "stages: [{name: a, type: multiply, params: {factor: two}}]",
+			err:  ErrInvalidParam,
+		},
+		{
+			name: "invalid duration",
+			def:  "stages: [{name: a, type: batch, params: {size: 2, interval: 10}}]",
+			err:  ErrInvalidParam,
+		},
+		{
+			name: "missing required param",
+			def:  "stages: [{name: a, type: debounce}]",
+			err:  ErrInvalidParam,
+		},
+		{
+			name: "duplicate name",
+			def:  "stages: [{name: a, type: multiply}, {name: a, type: multiply}]",
+			err:  ErrInvalidDefinition,
+		},
+		{
+			name: "negative buffer",
+			def:  "stages: [{name: a, type: multiply, buffer: -1}]",
+			err:  ErrInvalidDefinition,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			def, err := ParseYAML([]byte(tc.def))
+			require.NoError(t, err)
+			_, err = testRegistry(t).Build(def, nil)
+			require.Truef(t, errors.Is(err, tc.err), "actual err - %v", err)
+		})
+	}
+
+	_, err := ParseYAML([]byte("stages: [{name: a, type: multiply, workers: 2}]"))
+	require.Truef(t, errors.Is(err, ErrInvalidDefinition), "actual err - %v", err)
+	_, err = ParseJSON([]byte(`{"stages": [{"name": "a", "typo": "multiply"}]}`))
+	require.Truef(t, errors.Is(err, ErrInvalidDefinition), "actual err - %v", err)
+
+	err = testRegistry(t).Register("batch", nil)
+	require.Truef(t, errors.Is(err, ErrFactoryExists), "actual err - %v", err)
+}
+
+func TestLoadDefinition(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "pipeline.yml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("name: a\nstages: [{type: throttle, params: {per_second: 10}}]\n"), 0o600))
+	def, err := LoadDefinition(yamlPath)
+	require.NoError(t, err)
+	require.Equal(t, "throttle", def.Stages[0].Type)
+
+	p, err := NewRegistry().Build(def, nil)
+	require.NoError(t, err)
+	require.Equal(t, "0:throttle", p.Stats()[0].Name)
+
+	txtPath := filepath.Join(dir, "pipeline.txt")
+	require.NoError(t, os.WriteFile(txtPath, []byte("name: a"), 0o600))
+	_, err = LoadDefinition(txtPath)
+	require.Truef(t, errors.Is(err, ErrInvalidDefinition), "actual err - %v", err)
+
+	_, err = LoadDefinition(filepath.Join(dir, "missing.json"))
+	require.Truef(t, errors.Is(err, os.ErrNotExist), "actual err - %v", err)
+}
@@ -0,0 +1,187 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrNoDeadLetter = errors.New("dead letter channel is not set")
+
+// ErrorPolicy определяет, что делать со значением, на котором стейдж вернул ошибку.
+type ErrorPolicy int
+
+const (
+	// SkipOnError отбрасывает значение и продолжает обработку
+	SkipOnError ErrorPolicy = iota
+	// DeadLetterOnError отправляет *StageError в ErrorConfig.DeadLetter и продолжает обработку
+	DeadLetterOnError
+	// AbortOnError останавливает весь пайплайн и возвращает ошибку
+	AbortOnError
+)
+
+// ErrStage обрабатывает одно значение и может вернуть ошибку.
+type ErrStage func(ctx context.Context, v interface{}) (interface{}, error)
+
+type ErrorConfig struct {
+	Policy ErrorPolicy
+	// DeadLetter обязателен для DeadLetterOnError. Отправка в него прерывается по done и ctx
+	DeadLetter chan<- *StageError
+}
+
+type StageError struct {
+	// Stage - номер стейджа, начиная с 0
+	Stage int
+	Value interface{}
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("stage %d: %s", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// ExecuteErrPipeline запускает пайплайн из стейджей, возвращающих ошибки. Ошибки обрабатываются
+// согласно cfg.Policy. Канал ошибок получает не больше одной ошибки - причину остановки
+// (*StageError для AbortOnError или ошибку ctx) - и закрывается после остановки всех стейджей.
+func ExecuteErrPipeline(ctx context.Context, in In, done In, cfg ErrorConfig, stages ...ErrStage) (Out, <-chan error) {
+	errc := make(chan error, 1)
+	if cfg.Policy == DeadLetterOnError && cfg.DeadLetter == nil {
+		out := make(Bi)
+		close(out)
+		errc <- ErrNoDeadLetter
+		close(errc)
+		return out, errc
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	p := &errPipeline{
+		ctx:    runCtx,
+		cancel: cancel,
+		done:   done,
+		cfg:    cfg,
+	}
+	out := p.forward(in)
+	for i, stage := range stages {
+		if stage == nil {
+			continue
+		}
+		out = p.runStage(i, stage, out)
+	}
+
+	go func() {
+		p.wg.Wait()
+		if p.err == nil && ctx.Err() != nil {
+			p.err = ctx.Err()
+		}
+		if p.err != nil {
+			errc <- p.err
+		}
+		cancel()
+		close(errc)
+	}()
+	return out, errc
+}
+
+type errPipeline struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   In
+	cfg    ErrorConfig
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+func (p *errPipeline) runStage(index int, stage ErrStage, in In) Out {
+	out := make(Bi)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(out)
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-p.ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				res, err := stage(p.ctx, v)
+				if err != nil {
+					if !p.handle(&StageError{Stage: index, Value: v, Err: err}) {
+						return
+					}
+					continue
+				}
+				if !p.send(out, res) {
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// forward передает входные значения первому стейджу, учитывая остановку пайплайна.
+func (p *errPipeline) forward(in In) Out {
+	out := make(Bi)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(out)
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-p.ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok || !p.send(out, v) {
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// handle обрабатывает ошибку стейджа и возвращает false, если стейдж должен остановиться.
+func (p *errPipeline) handle(err *StageError) bool {
+	switch p.cfg.Policy {
+	case SkipOnError:
+		return true
+	case DeadLetterOnError:
+		select {
+		case <-p.done:
+			return false
+		case <-p.ctx.Done():
+			return false
+		case p.cfg.DeadLetter <- err:
+			return true
+		}
+	case AbortOnError:
+		p.once.Do(func() {
+			p.err = err
+			p.cancel()
+		})
+	}
+	return false
+}
+
+func (p *errPipeline) send(out Bi, v interface{}) bool {
+	select {
+	case <-p.done:
+		return false
+	case <-p.ctx.Done():
+		return false
+	case out <- v:
+		return true
+	}
+}
@@ -0,0 +1,161 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errOdd = errors.New("odd value")
+
+func produce(data ...interface{}) In {
+	in := make(Bi)
+	go func() {
+		defer close(in)
+		for _, v := range data {
+			in <- v
+		}
+	}()
+	return in
+}
+
+func TestExecuteErrPipeline(t *testing.T) {
+	evenOnly := func(ctx context.Context, v interface{}) (interface{}, error) {
+		if v.(int)%2 != 0 {
+			return nil, errOdd
+		}
+		return v, nil
+	}
+	stringify := func(ctx context.Context, v interface{}) (interface{}, error) {
+		return strconv.Itoa(v.(int) * 10), nil
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		out, errc := ExecuteErrPipeline(context.Background(), produce(1, 2, 3, 4), nil,
+			ErrorConfig{Policy: SkipOnError}, evenOnly, stringify)
+
+		result := make([]string, 0)
+		for v := range out {
+			result = append(result, v.(string))
+		}
+		require.Equal(t, []string{"20", "40"}, result)
+		require.NoError(t, <-errc)
+	})
+
+	t.Run("dead letter", func(t *testing.T) {
+		dlq := make(chan *StageError, 10)
+		out, errc := ExecuteErrPipeline(context.Background(), produce(1, 2, 3, 4, 5), nil,
+			ErrorConfig{Policy: DeadLetterOnError, DeadLetter: dlq}, stringify, nil, func(ctx context.Context, v interface{}) (interface{}, error) {
+				n, _ := strconv.Atoi(v.(string))
+				return evenOnly(ctx, n/10)
+			})
+
+		result := make([]int, 0)
+		for v := range out {
+			result = append(result, v.(int))
+		}
+		require.NoError(t, <-errc)
+		require.Equal(t, []int{2, 4}, result)
+
+		close(dlq)
+		failed := make([]interface{}, 0)
+		for err := range dlq {
+			require.Equal(t, 2, err.Stage)
+			require.True(t, errors.Is(err, errOdd))
+			failed = append(failed, err.Value)
+		}
+		require.Equal(t, []interface{}{"10", "30", "50"}, failed)
+	})
+
+	t.Run("dead letter is not set", func(t *testing.T) {
+		out, errc := ExecuteErrPipeline(context.Background(), produce(1), nil,
+			ErrorConfig{Policy: DeadLetterOnError}, evenOnly)
+		_, ok := <-out
+		require.False(t, ok)
+		require.True(t, errors.Is(<-errc, ErrNoDeadLetter))
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		in := make(Bi)
+		go func() {
+			// после ошибки пайплайн перестает читать вход, поэтому отправка прерывается
+			for i := 2; ; i++ {
+				select {
+				case in <- i:
+				case <-time.After(time.Second):
+					return
+				}
+				if i == 5 {
+					return
+				}
+			}
+		}()
+		out, errc := ExecuteErrPipeline(context.Background(), in, nil,
+			ErrorConfig{Policy: AbortOnError}, evenOnly, stringify)
+
+		result := make([]string, 0)
+		for v := range out {
+			result = append(result, v.(string))
+		}
+		require.LessOrEqual(t, len(result), 1)
+
+		err := <-errc
+		var stageErr *StageError
+		require.True(t, errors.As(err, &stageErr), "actual err - %v", err)
+		require.Equal(t, 0, stageErr.Stage)
+		require.Equal(t, 3, stageErr.Value)
+		require.True(t, errors.Is(err, errOdd))
+		_, ok := <-errc
+		require.False(t, ok)
+	})
+
+	t.Run("context cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		in := make(Bi)
+		out, errc := ExecuteErrPipeline(ctx, in, nil, ErrorConfig{}, stringify)
+		in <- 1
+		require.Equal(t, "10", <-out)
+		cancel()
+
+		_, ok := <-out
+		require.False(t, ok)
+		require.True(t, errors.Is(<-errc, context.Canceled))
+	})
+
+	t.Run("stage sees context", func(t *testing.T) {
+		type key struct{}
+		ctx := context.WithValue(context.Background(), key{}, "value")
+		out, errc := ExecuteErrPipeline(ctx, produce(1), nil, ErrorConfig{},
+			func(ctx context.Context, v interface{}) (interface{}, error) {
+				return ctx.Value(key{}), nil
+			})
+		require.Equal(t, "value", <-out)
+		require.NoError(t, <-errc)
+	})
+
+	t.Run("without stages", func(t *testing.T) {
+		out, errc := ExecuteErrPipeline(context.Background(), produce(1, 2, 3), nil, ErrorConfig{})
+		result := make([]int, 0)
+		for v := range out {
+			result = append(result, v.(int))
+		}
+		require.Equal(t, []int{1, 2, 3}, result)
+		require.NoError(t, <-errc)
+	})
+}
+
+func TestExecutePipelineContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(Bi)
+	out := ExecutePipelineContext(ctx, in, nil)
+	in <- 1
+	require.Equal(t, 1, <-out)
+	cancel()
+
+	_, ok := <-out
+	require.False(t, ok)
+}
@@ -0,0 +1,149 @@
+package hw06pipelineexecution
+
+import "sync"
+
+// ParallelOptions настраивает параллельное выполнение стейджа.
+type ParallelOptions struct {
+	// Workers - число горутин, обрабатывающих значения, по умолчанию 1
+	Workers int
+	// Ordered - выдавать результаты в порядке поступления значений на вход
+	Ordered bool
+	// Window ограничивает число значений в обработке и в буфере переупорядочивания
+	// при Ordered, по умолчанию 2*Workers
+	Window int
+}
+
+func (o ParallelOptions) normalize() ParallelOptions {
+	if o.Workers <= 0 {
+		o.Workers = 1
+	}
+	if o.Window < o.Workers {
+		o.Window = 2 * o.Workers
+	}
+	return o
+}
+
+// ParallelMap строит стейдж, применяющий f к значениям в opts.Workers горутинах.
+func ParallelMap[I, O any](done In, f func(I) O, opts ParallelOptions) TypedStage[I, O] {
+	opts = opts.normalize()
+	return func(in <-chan I) <-chan O {
+		if opts.Ordered {
+			return orderedMap(done, in, f, opts)
+		}
+		out := make(chan O)
+		wg := sync.WaitGroup{}
+		wg.Add(opts.Workers)
+		for i := 0; i < opts.Workers; i++ {
+			go func() {
+				defer wg.Done()
+				for v := range in {
+					select {
+					case <-done:
+						return
+					case out <- f(v):
+					}
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(out)
+		}()
+		return out
+	}
+}
+
+// Parallel - ParallelMap для нетипизированных значений ExecutePipeline.
+func Parallel(done In, f func(v interface{}) interface{}, opts ParallelOptions) Stage {
+	return Untyped(ParallelMap(done, f, opts))
+}
+
+type sequenced[T any] struct {
+	seq int
+	v   T
+}
+
+// orderedMap нумерует входные значения и выдает результаты по порядку номеров.
+// Семафор на opts.Window значений ограничивает и обработку, и буфер ожидающих результатов.
+func orderedMap[I, O any](done In, in <-chan I, f func(I) O, opts ParallelOptions) <-chan O {
+	window := make(chan struct{}, opts.Window)
+	jobs := make(chan sequenced[I])
+	results := make(chan sequenced[O])
+	out := make(chan O)
+
+	go func() {
+		defer close(jobs)
+		seq := 0
+		for v := range in {
+			select {
+			case <-done:
+				return
+			case window <- struct{}{}:
+			}
+			select {
+			case <-done:
+				return
+			case jobs <- sequenced[I]{seq: seq, v: v}:
+			}
+			seq++
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				select {
+				case <-done:
+					return
+				case results <- sequenced[O]{seq: j.seq, v: f(j.v)}:
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go func() {
+		defer close(out)
+		pending := make(map[int]O, opts.Window)
+		next := 0
+		for r := range results {
+			pending[r.seq] = r.v
+			for {
+				v, ok := pending[next]
+				if !ok {
+					break
+				}
+				select {
+				case <-done:
+					return
+				case out <- v:
+				}
+				delete(pending, next)
+				next++
+				<-window
+			}
+		}
+	}()
+	return out
+}
+
+// FanOut запускает workers копий stage, читающих общий вход, и объединяет их выходы.
+// Порядок значений не сохраняется.
+func FanOut(done In, workers int, stage Stage) Stage {
+	if workers <= 1 {
+		return stage
+	}
+	return func(in In) Out {
+		outs := make([]In, 0, workers)
+		for i := 0; i < workers; i++ {
+			outs = append(outs, stage(in))
+		}
+		return Merge(done, outs...)
+	}
+}
@@ -0,0 +1,151 @@
+package hw06pipelineexecution
+
+import (
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelMap(t *testing.T) {
+	data := make([]int, 0, 100)
+	for i := 0; i < 100; i++ {
+		data = append(data, i)
+	}
+	square := func(v int) int {
+		time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+		return v * v
+	}
+	expected := make([]int, 0, len(data))
+	for _, v := range data {
+		expected = append(expected, v*v)
+	}
+
+	t.Run("unordered", func(t *testing.T) {
+		result := make([]int, 0, len(data))
+		stage := ParallelMap(nil, square, ParallelOptions{Workers: 8})
+		for v := range ExecuteTyped(produceInts(data...), nil, stage) {
+			result = append(result, v)
+		}
+		sort.Ints(result)
+		require.Equal(t, expected, result)
+	})
+
+	t.Run("ordered", func(t *testing.T) {
+		result := make([]int, 0, len(data))
+		stage := ParallelMap(nil, square, ParallelOptions{Workers: 8, Ordered: true, Window: 16})
+		for v := range ExecuteTyped(produceInts(data...), nil, stage) {
+			result = append(result, v)
+		}
+		require.Equal(t, expected, result)
+	})
+
+	t.Run("workers run concurrently", func(t *testing.T) {
+		sleep := 50 * time.Millisecond
+		stage := Parallel(nil, func(v interface{}) interface{} {
+			time.Sleep(sleep)
+			return v
+		}, ParallelOptions{Workers: 4, Ordered: true})
+
+		start := time.Now()
+		count := 0
+		for range ExecutePipeline(produce(1, 2, 3, 4, 5, 6, 7, 8), nil, stage) {
+			count++
+		}
+		require.Equal(t, 8, count)
+		require.Less(t, int64(time.Since(start)), int64(4*sleep))
+	})
+
+	t.Run("reorder buffer is bounded", func(t *testing.T) {
+		window := 4
+		release := make(chan struct{})
+		var taken int32
+		in := make(chan int)
+		go func() {
+			defer close(in)
+			for i := 0; i < 100; i++ {
+				in <- i
+				atomic.AddInt32(&taken, 1)
+			}
+		}()
+		stage := ParallelMap(nil, func(v int) int {
+			if v == 0 {
+				// первое значение задерживается, остальные копятся в буфере
+				<-release
+			}
+			return v
+		}, ParallelOptions{Workers: 2, Ordered: true, Window: window})
+		out := stage(in)
+
+		time.Sleep(50 * time.Millisecond)
+		// одно значение может ждать места в окне у диспетчера
+		require.LessOrEqual(t, atomic.LoadInt32(&taken), int32(window+1))
+		close(release)
+
+		next := 0
+		for v := range out {
+			require.Equal(t, next, v)
+			next++
+		}
+		require.Equal(t, 100, next)
+	})
+
+	t.Run("done", func(t *testing.T) {
+		done := make(Bi)
+		in := make(Bi)
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				case in <- i:
+				}
+			}
+		}()
+		stage := Parallel(done, func(v interface{}) interface{} { return v }, ParallelOptions{Workers: 4, Ordered: true})
+		out := ExecutePipeline(in, done, stage)
+		for i := 0; i < 10; i++ {
+			<-out
+		}
+		close(done)
+		for range out {
+		}
+	})
+}
+
+func TestFanOut(t *testing.T) {
+	sleep := 50 * time.Millisecond
+	var maxRunning, running int32
+	stage := func(in In) Out {
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			for v := range in {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&maxRunning)
+					if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+						break
+					}
+				}
+				time.Sleep(sleep)
+				atomic.AddInt32(&running, -1)
+				out <- v.(int) * 2
+			}
+		}()
+		return out
+	}
+
+	start := time.Now()
+	result := make([]int, 0)
+	for v := range ExecutePipeline(produce(1, 2, 3, 4, 5, 6), nil, FanOut(nil, 3, stage)) {
+		result = append(result, v.(int))
+	}
+	sort.Ints(result)
+	require.Equal(t, []int{2, 4, 6, 8, 10, 12}, result)
+	require.Equal(t, int32(3), atomic.LoadInt32(&maxRunning))
+	require.Less(t, int64(time.Since(start)), int64(6*sleep))
+}
@@ -1,5 +1,7 @@
 package hw06pipelineexecution
 
+import "context"
+
 type (
 	In  = <-chan interface{}
 	Out = In
@@ -8,32 +10,23 @@ type (
 
 type Stage func(in In) (out Out)
 
-func runStage(done In, in In) Out {
-	out := make(Bi)
-	go func() {
-		defer close(out)
-		for {
-			select {
-			case <-done:
-				return
-			case v, ok := <-in:
-				if !ok {
-					return
-				}
-				out <- v
-			}
-		}
-	}()
-	return out
+func runStage(ctx context.Context, done In, in In) Out {
+	return forward(ctx, done, in)
 }
 
 func ExecutePipeline(in In, done In, stages ...Stage) Out {
-	out := runStage(done, in)
+	return ExecutePipelineContext(context.Background(), in, done, stages...)
+}
+
+// ExecutePipelineContext работает как ExecutePipeline, но дополнительно останавливает пайплайн
+// при отмене ctx.
+func ExecutePipelineContext(ctx context.Context, in In, done In, stages ...Stage) Out {
+	out := runStage(ctx, done, in)
 	for _, stage := range stages {
 		if stage == nil {
 			continue
 		}
-		out = stage(runStage(done, out))
+		out = stage(runStage(ctx, done, out))
 	}
 	return out
 }
@@ -0,0 +1,147 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Pipeline - пайплайн с буферами между стейджами и счетчиками по каждому стейджу.
+type Pipeline struct {
+	stages []*pipelineStage
+	// output - связь последнего стейджа с потребителем
+	output *link
+}
+
+type pipelineStage struct {
+	name  string
+	stage Stage
+	input *link
+}
+
+// StageStats - снимок счетчиков стейджа. Стейдж - узкое место, если у него растет
+// BlockedOnSend (вход забит, стейдж не успевает), а у следующего - BlockedOnReceive.
+type StageStats struct {
+	Name string
+	// In - сколько значений передано в стейдж, Out - сколько он выдал
+	In  int64
+	Out int64
+	// Buffer - размер входного буфера, Buffered - сколько значений в нем сейчас
+	Buffer   int
+	Buffered int
+	// BlockedOnSend - сколько времени значения ждали места во входе стейджа
+	BlockedOnSend time.Duration
+	// BlockedOnReceive - сколько времени выход стейджа ждали следующего значения
+	BlockedOnReceive time.Duration
+}
+
+func NewPipeline() *Pipeline {
+	return &Pipeline{
+		output: &link{},
+	}
+}
+
+// Add добавляет стейдж с входным буфером на buffer значений (0 - без буфера).
+func (p *Pipeline) Add(name string, stage Stage, buffer int) *Pipeline {
+	if buffer < 0 {
+		buffer = 0
+	}
+	p.stages = append(p.stages, &pipelineStage{
+		name:  name,
+		stage: stage,
+		input: &link{buffer: buffer},
+	})
+	return p
+}
+
+// Execute запускает пайплайн так же, как ExecutePipelineContext. Счетчики сбрасываются
+// при каждом запуске.
+func (p *Pipeline) Execute(ctx context.Context, in In, done In) Out {
+	out := in
+	for _, s := range p.stages {
+		s.input.reset()
+		out = s.stage(s.input.run(ctx, done, out))
+	}
+	p.output.reset()
+	return p.output.run(ctx, done, out)
+}
+
+// Stats возвращает снимок счетчиков, его можно брать во время работы пайплайна.
+func (p *Pipeline) Stats() []StageStats {
+	stats := make([]StageStats, 0, len(p.stages))
+	for i, s := range p.stages {
+		next := p.output
+		if i+1 < len(p.stages) {
+			next = p.stages[i+1].input
+		}
+		stats = append(stats, StageStats{
+			Name:             s.name,
+			In:               atomic.LoadInt64(&s.input.sent),
+			Out:              atomic.LoadInt64(&next.received),
+			Buffer:           s.input.buffer,
+			Buffered:         s.input.buffered(),
+			BlockedOnSend:    time.Duration(atomic.LoadInt64(&s.input.blockedSend)),
+			BlockedOnReceive: time.Duration(atomic.LoadInt64(&next.blockedRecv)),
+		})
+	}
+	return stats
+}
+
+// link передает значения между стейджами как runStage и считает время ожидания.
+type link struct {
+	buffer      int
+	ch          atomic.Value
+	received    int64
+	sent        int64
+	blockedRecv int64
+	blockedSend int64
+}
+
+func (l *link) reset() {
+	atomic.StoreInt64(&l.received, 0)
+	atomic.StoreInt64(&l.sent, 0)
+	atomic.StoreInt64(&l.blockedRecv, 0)
+	atomic.StoreInt64(&l.blockedSend, 0)
+}
+
+func (l *link) buffered() int {
+	if ch, ok := l.ch.Load().(Bi); ok {
+		return len(ch)
+	}
+	return 0
+}
+
+func (l *link) run(ctx context.Context, done In, in In) Out {
+	out := make(Bi, l.buffer)
+	l.ch.Store(out)
+	go func() {
+		defer close(out)
+		for {
+			start := time.Now()
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				atomic.AddInt64(&l.blockedRecv, int64(time.Since(start)))
+				atomic.AddInt64(&l.received, 1)
+
+				start = time.Now()
+				select {
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
+				case out <- v:
+				}
+				atomic.AddInt64(&l.blockedSend, int64(time.Since(start)))
+				atomic.AddInt64(&l.sent, 1)
+			}
+		}
+	}()
+	return out
+}
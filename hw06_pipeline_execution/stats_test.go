@@ -0,0 +1,105 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sleepStage(d time.Duration) Stage {
+	return func(in In) Out {
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			for v := range in {
+				time.Sleep(d)
+				out <- v
+			}
+		}()
+		return out
+	}
+}
+
+func TestPipelineStats(t *testing.T) {
+	data := []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	p := NewPipeline().
+		Add("fast", sleepStage(0), 0).
+		Add("slow", sleepStage(20*time.Millisecond), 4).
+		Add("filter", func(in In) Out {
+			out := make(Bi)
+			go func() {
+				defer close(out)
+				for v := range in {
+					if v.(int)%2 == 0 {
+						out <- v
+					}
+				}
+			}()
+			return out
+		}, 0)
+
+	result := collect(p.Execute(context.Background(), produce(data...), nil))
+	require.Equal(t, []interface{}{2, 4, 6, 8, 10}, result)
+
+	stats := p.Stats()
+	require.Len(t, stats, 3)
+	require.Equal(t, "fast", stats[0].Name)
+	require.Equal(t, int64(10), stats[0].In)
+	require.Equal(t, int64(10), stats[0].Out)
+	require.Equal(t, int64(10), stats[1].In)
+	require.Equal(t, int64(10), stats[1].Out)
+	require.Equal(t, 4, stats[1].Buffer)
+	require.Equal(t, 0, stats[1].Buffered)
+	require.Equal(t, int64(10), stats[2].In)
+	require.Equal(t, int64(5), stats[2].Out)
+
+	// медленный стейдж задерживает запись в свой вход и чтение выхода
+	require.Greater(t, int64(stats[1].BlockedOnSend), int64(stats[0].BlockedOnSend))
+	require.Greater(t, int64(stats[1].BlockedOnReceive), int64(stats[0].BlockedOnReceive))
+	require.Greater(t, int64(stats[1].BlockedOnSend), int64(50*time.Millisecond))
+}
+
+func TestPipelineBuffer(t *testing.T) {
+	block := make(chan struct{})
+	p := NewPipeline().Add("blocked", func(in In) Out {
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			<-block
+			for v := range in {
+				out <- v
+			}
+		}()
+		return out
+	}, 3)
+
+	in := make(Bi)
+	out := p.Execute(context.Background(), in, nil)
+	for i := 0; i < 3; i++ {
+		// стейдж не читает вход, но буфер принимает значения
+		in <- i
+	}
+	require.Eventually(t, func() bool {
+		return p.Stats()[0].Buffered == 3
+	}, time.Second, time.Millisecond)
+
+	close(block)
+	close(in)
+	require.Equal(t, []interface{}{0, 1, 2}, collect(out))
+}
+
+func TestPipelineDone(t *testing.T) {
+	done := make(Bi)
+	p := NewPipeline().Add("slow", sleepStage(10*time.Millisecond), 2)
+	in := make(Bi)
+	out := p.Execute(context.Background(), in, done)
+	close(done)
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("pipeline is not stopped by done")
+	case <-drain(out):
+	}
+}
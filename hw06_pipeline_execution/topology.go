@@ -0,0 +1,124 @@
+package hw06pipelineexecution
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Tee отправляет каждое значение из in во все n веток. Следующее значение читается только
+// после того, как текущее приняли все ветки, поэтому медленная ветка тормозит остальные.
+func Tee(done In, in In, n int) []Out {
+	outs := make([]Bi, n)
+	result := make([]Out, n)
+	for i := range outs {
+		outs[i] = make(Bi)
+		result[i] = outs[i]
+	}
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+		for {
+			select {
+			case <-done:
+				return
+			case v, ok := <-in:
+				if !ok || !broadcast(done, outs, v) {
+					return
+				}
+			}
+		}
+	}()
+	return result
+}
+
+// broadcast отправляет v во все outs в том порядке, в котором их готовы читать.
+func broadcast(done In, outs []Bi, v interface{}) bool {
+	cases := make([]reflect.SelectCase, 0, len(outs)+1)
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)})
+	for _, out := range outs {
+		cases = append(cases, reflect.SelectCase{
+			Dir:  reflect.SelectSend,
+			Chan: reflect.ValueOf(out),
+			Send: reflect.ValueOf(&v).Elem(),
+		})
+	}
+	for pending := len(outs); pending > 0; pending-- {
+		chosen, _, _ := reflect.Select(cases)
+		if chosen == 0 {
+			return false
+		}
+		// nil-канал в select никогда не выбирается
+		cases[chosen].Chan = reflect.ValueOf(Bi(nil))
+	}
+	return true
+}
+
+// Route отправляет каждое значение в ветку первого предиката, вернувшего true.
+// Возвращает len(predicates)+1 веток, последняя получает значения, не подошедшие ни под один предикат.
+func Route(done In, in In, predicates ...func(v interface{}) bool) []Out {
+	outs := make([]Bi, len(predicates)+1)
+	result := make([]Out, len(outs))
+	for i := range outs {
+		outs[i] = make(Bi)
+		result[i] = outs[i]
+	}
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+		for {
+			select {
+			case <-done:
+				return
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				branch := len(predicates)
+				for i, match := range predicates {
+					if match(v) {
+						branch = i
+						break
+					}
+				}
+				if !send(done, outs[branch], v) {
+					return
+				}
+			}
+		}
+	}()
+	return result
+}
+
+// Merge объединяет значения из всех ins в один канал, который закрывается после закрытия
+// всех входов или done.
+func Merge(done In, ins ...In) Out {
+	out := make(Bi)
+	wg := sync.WaitGroup{}
+	wg.Add(len(ins))
+	for _, in := range ins {
+		go func(in In) {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				case v, ok := <-in:
+					if !ok || !send(done, out, v) {
+						return
+					}
+				}
+			}
+		}(in)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
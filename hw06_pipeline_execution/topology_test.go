@@ -0,0 +1,146 @@
+package hw06pipelineexecution
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func collectAll(outs []Out) [][]interface{} {
+	results := make([][]interface{}, len(outs))
+	wg := sync.WaitGroup{}
+	wg.Add(len(outs))
+	for i, out := range outs {
+		go func(i int, out Out) {
+			defer wg.Done()
+			results[i] = collect(out)
+		}(i, out)
+	}
+	wg.Wait()
+	return results
+}
+
+func TestTee(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	t.Run("broadcast", func(t *testing.T) {
+		outs := Tee(nil, produce(1, 2, 3, nil), 3)
+		for _, result := range collectAll(outs) {
+			require.Equal(t, []interface{}{1, 2, 3, nil}, result)
+		}
+	})
+
+	t.Run("branches read in any order", func(t *testing.T) {
+		outs := Tee(nil, produce(1, 2), 2)
+		// вторая ветка читается раньше первой
+		require.Equal(t, 1, <-outs[1])
+		require.Equal(t, 1, <-outs[0])
+		require.Equal(t, 2, <-outs[1])
+		require.Equal(t, 2, <-outs[0])
+		_, ok := <-outs[0]
+		require.False(t, ok)
+		_, ok = <-outs[1]
+		require.False(t, ok)
+	})
+
+	t.Run("done", func(t *testing.T) {
+		done := make(Bi)
+		in := make(Bi)
+		outs := Tee(done, in, 2)
+		go func() {
+			in <- 1
+		}()
+		require.Equal(t, 1, <-outs[0])
+		// вторая ветка не читает, Tee ждет ее до закрытия done
+		close(done)
+		collectAll(outs)
+	})
+}
+
+func TestRoute(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	t.Run("predicates", func(t *testing.T) {
+		isEven := func(v interface{}) bool { return v.(int)%2 == 0 }
+		isSmall := func(v interface{}) bool { return v.(int) < 5 }
+		outs := Route(nil, produce(1, 2, 3, 4, 5, 6, 7, 8), isEven, isSmall)
+		require.Len(t, outs, 3)
+
+		results := collectAll(outs)
+		require.Equal(t, []interface{}{2, 4, 6, 8}, results[0])
+		require.Equal(t, []interface{}{1, 3}, results[1])
+		require.Equal(t, []interface{}{5, 7}, results[2])
+	})
+
+	t.Run("done", func(t *testing.T) {
+		done := make(Bi)
+		in := make(Bi)
+		outs := Route(done, in, func(v interface{}) bool { return true })
+		go func() {
+			in <- 1
+		}()
+		time.Sleep(10 * time.Millisecond)
+		close(done)
+		collectAll(outs)
+	})
+}
+
+func TestMerge(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	t.Run("all values", func(t *testing.T) {
+		out := Merge(nil, produce(1, 2), produce(3), produce())
+		result := make([]int, 0)
+		for v := range out {
+			result = append(result, v.(int))
+		}
+		sort.Ints(result)
+		require.Equal(t, []int{1, 2, 3}, result)
+	})
+
+	t.Run("no inputs", func(t *testing.T) {
+		require.Len(t, collect(Merge(nil)), 0)
+	})
+
+	t.Run("done", func(t *testing.T) {
+		done := make(Bi)
+		in := make(Bi)
+		out := Merge(done, in, make(Bi))
+		go func() {
+			in <- 1
+		}()
+		require.Equal(t, 1, <-out)
+		close(done)
+		collect(out)
+	})
+}
+
+func TestSplitMergePipeline(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	done := make(Bi)
+	defer close(done)
+
+	isEven := func(v interface{}) bool { return v.(int)%2 == 0 }
+	branches := Route(done, produce(1, 2, 3, 4, 5, 6), isEven)
+	double := Untyped(Map(func(v int) int { return v * 2 }))
+	negate := Untyped(Map(func(v int) int { return -v }))
+
+	even := ExecutePipeline(branches[0], done, double)
+	odd := ExecutePipeline(branches[1], done, negate)
+	copies := Tee(done, Merge(done, even, odd), 2)
+
+	results := collectAll(copies)
+	for _, result := range results {
+		values := make([]int, 0)
+		for _, v := range result {
+			values = append(values, v.(int))
+		}
+		sort.Ints(values)
+		require.Equal(t, []int{-5, -3, -1, 4, 8, 12}, values)
+	}
+}
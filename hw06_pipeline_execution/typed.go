@@ -0,0 +1,126 @@
+package hw06pipelineexecution
+
+import "context"
+
+// TypedStage - стейдж с типизированными входом и выходом. Цепочка из несовместимых
+// по типам стейджей не скомпилируется.
+type TypedStage[I, O any] func(in <-chan I) <-chan O
+
+// Map строит стейдж, применяющий f к каждому значению.
+func Map[I, O any](f func(I) O) TypedStage[I, O] {
+	return func(in <-chan I) <-chan O {
+		out := make(chan O)
+		go func() {
+			defer close(out)
+			for v := range in {
+				out <- f(v)
+			}
+		}()
+		return out
+	}
+}
+
+// Filter строит стейдж, пропускающий только значения, для которых keep вернул true.
+func Filter[T any](keep func(T) bool) TypedStage[T, T] {
+	return func(in <-chan T) <-chan T {
+		out := make(chan T)
+		go func() {
+			defer close(out)
+			for v := range in {
+				if keep(v) {
+					out <- v
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// Chain соединяет два стейджа: выход first становится входом second.
+func Chain[A, B, C any](first TypedStage[A, B], second TypedStage[B, C]) TypedStage[A, C] {
+	return func(in <-chan A) <-chan C {
+		return second(first(in))
+	}
+}
+
+// ExecuteTyped запускает типизированный стейдж (обычно собранный через Chain)
+// с остановкой через done, как ExecutePipeline.
+func ExecuteTyped[I, O any](in <-chan I, done In, stage TypedStage[I, O]) <-chan O {
+	staged := stage(forward(context.Background(), done, in))
+	out := make(chan O)
+	go func() {
+		// стейджи не знают о done: после остановки дочитываем их выход, иначе горутины
+		// стейджей зависнут на отправке. Вход стейджа уже закрыт, так что выход тоже закроется.
+		defer func() {
+			for range staged {
+			}
+		}()
+		defer close(out)
+		for {
+			select {
+			case <-done:
+				return
+			case v, ok := <-staged:
+				if !ok {
+					return
+				}
+				select {
+				case <-done:
+					return
+				case out <- v:
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// Untyped превращает типизированный стейдж в Stage для ExecutePipeline.
+// Значения неподходящего типа на входе пропускаются.
+func Untyped[I, O any](stage TypedStage[I, O]) Stage {
+	return func(in In) Out {
+		typed := make(chan I)
+		go func() {
+			defer close(typed)
+			for v := range in {
+				if value, ok := v.(I); ok {
+					typed <- value
+				}
+			}
+		}()
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			for v := range stage(typed) {
+				out <- v
+			}
+		}()
+		return out
+	}
+}
+
+// FromStage позволяет использовать существующий Stage в Chain.
+func FromStage(stage Stage) TypedStage[interface{}, interface{}] {
+	return TypedStage[interface{}, interface{}](stage)
+}
+
+func forward[T any](ctx context.Context, done In, in <-chan T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				out <- v
+			}
+		}
+	}()
+	return out
+}
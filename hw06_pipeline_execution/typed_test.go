@@ -0,0 +1,115 @@
+package hw06pipelineexecution
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func produceInts(data ...int) <-chan int {
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for _, v := range data {
+			in <- v
+		}
+	}()
+	return in
+}
+
+func TestTypedPipeline(t *testing.T) {
+	double := Map(func(v int) int { return v * 2 })
+	even := Filter(func(v int) bool { return v%4 == 0 })
+	stringify := Map(strconv.Itoa)
+	pipeline := Chain(Chain(double, even), stringify)
+
+	t.Run("execute typed", func(t *testing.T) {
+		result := make([]string, 0)
+		for v := range ExecuteTyped(produceInts(1, 2, 3, 4), nil, pipeline) {
+			result = append(result, v)
+		}
+		require.Equal(t, []string{"4", "8"}, result)
+	})
+
+	t.Run("done", func(t *testing.T) {
+		done := make(Bi)
+		close(done)
+		result := make([]string, 0)
+		for v := range ExecuteTyped(make(chan int), done, pipeline) {
+			result = append(result, v)
+		}
+		require.Len(t, result, 0)
+	})
+
+	t.Run("typed stage in ExecutePipeline", func(t *testing.T) {
+		in := produce(1, 2, 3, 4)
+		result := make([]string, 0)
+		for v := range ExecutePipeline(in, nil, Untyped(pipeline), Untyped(Map(func(s string) string { return s + "!" }))) {
+			result = append(result, v.(string))
+		}
+		require.Equal(t, []string{"4!", "8!"}, result)
+	})
+
+	t.Run("untyped skips wrong type", func(t *testing.T) {
+		result := make([]interface{}, 0)
+		for v := range ExecutePipeline(produce(1, "2", 3), nil, Untyped(double)) {
+			result = append(result, v)
+		}
+		require.Equal(t, []interface{}{2, 6}, result)
+	})
+
+	t.Run("existing stage in chain", func(t *testing.T) {
+		slow := func(in In) Out {
+			out := make(Bi)
+			go func() {
+				defer close(out)
+				for v := range in {
+					time.Sleep(time.Millisecond)
+					out <- v
+				}
+			}()
+			return out
+		}
+		toInt := Map(func(v interface{}) int { return v.(int) })
+		toAny := Map(func(v int) interface{} { return v })
+		chain := Chain(Chain(Chain(toAny, FromStage(slow)), toInt), double)
+
+		result := make([]int, 0)
+		for v := range ExecuteTyped(produceInts(1, 2, 3), nil, chain) {
+			result = append(result, v)
+		}
+		require.Equal(t, []int{2, 4, 6}, result)
+	})
+}
+
+func TestTypedPipelineDoneMidStream(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	done := make(Bi)
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			case in <- i:
+			}
+		}
+	}()
+	double := Map(func(v int) int { return v * 2 })
+	even := Filter(func(v int) bool { return v%4 == 0 })
+	pipeline := Chain(Chain(double, even), Map(strconv.Itoa))
+
+	out := ExecuteTyped(in, done, pipeline)
+	for i := 0; i < 3; i++ {
+		<-out
+	}
+	// стейджи в этот момент держат значения и ждут, пока их прочитают
+	close(done)
+	for range out {
+	}
+}
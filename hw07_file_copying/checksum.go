@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/cespare/xxhash/v2"
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	ErrUnknownHash      = errors.New("unknown hash algorithm")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+const (
+	HashSHA256  = "sha256"
+	HashBLAKE2b = "blake2b"
+	HashXXHash  = "xxhash"
+)
+
+func newHash(name string) (hash.Hash, error) {
+	switch name {
+	case HashSHA256:
+		return sha256.New(), nil
+	case HashBLAKE2b:
+		return blake2b.New512(nil)
+	case HashXXHash:
+		return xxhash.New(), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownHash, name)
+	}
+}
+
+// ChecksumError - перечитанный dst не совпал с данными, прочитанными из источника.
+type ChecksumError struct {
+	Hash        string
+	Source      []byte
+	Destination []byte
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s: %s source %x, destination %x", ErrChecksumMismatch, e.Hash, e.Source, e.Destination)
+}
+
+func (e *ChecksumError) Unwrap() error {
+	return ErrChecksumMismatch
+}
+
+// verifyChecksum перечитывает n байт file с позиции off и сравнивает их сумму с sum.
+func verifyChecksum(file *os.File, off, n int64, name string, sum []byte) error {
+	h, err := newHash(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(h, io.NewSectionReader(file, off, n)); err != nil {
+		return err
+	}
+	if got := h.Sum(nil); !bytes.Equal(got, sum) {
+		return &ChecksumError{Hash: name, Source: sum, Destination: got}
+	}
+	return nil
+}
+
+// writeChecksum записывает сумму в формате sha256sum, path "-" - стандартный вывод.
+func writeChecksum(path, name string, sum []byte) error {
+	line := fmt.Sprintf("%x  %s\n", sum, name)
+	if path == "-" {
+		_, err := io.WriteString(os.Stdout, line)
+		return err
+	}
+	return os.WriteFile(path, []byte(line), 0o644)
+}
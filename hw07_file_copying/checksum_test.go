@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestCopyChecksum(t *testing.T) {
+	expect, err := os.ReadFile("testdata/out_offset100_limit1000.txt")
+	require.NoError(t, err)
+	sha := sha256.Sum256(expect)
+	blake := blake2b.Sum512(expect)
+	xx := xxhash.New()
+	_, _ = xx.Write(expect)
+
+	tests := []struct {
+		hash string
+		sum  []byte
+	}{
+		{hash: "", sum: sha[:]},
+		{hash: HashSHA256, sum: sha[:]},
+		{hash: HashBLAKE2b, sum: blake[:]},
+		{hash: HashXXHash, sum: xx.Sum(nil)},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.hash, func(t *testing.T) {
+			dir := t.TempDir()
+			dstFile := dir + "/out.txt"
+			sumFile := dir + "/out.txt.sum"
+			err := CopyWithOptions("testdata/input.txt", dstFile, Options{
+				Offset:      100,
+				Limit:       1000,
+				Verify:      true,
+				Hash:        tc.hash,
+				ChecksumOut: sumFile,
+			})
+			require.NoError(t, err)
+
+			got, err := os.ReadFile(dstFile)
+			require.NoError(t, err)
+			require.Equal(t, string(expect), string(got))
+			line, err := os.ReadFile(sumFile)
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("%x  %s\n", tc.sum, dstFile), string(line))
+		})
+	}
+
+	t.Run("resume hashes whole range", func(t *testing.T) {
+		dir := t.TempDir()
+		dstFile := dir + "/out.txt"
+		sumFile := dir + "/out.txt.sum"
+		require.NoError(t, os.WriteFile(dstFile, expect[:300], 0o600))
+		err := CopyWithOptions("testdata/input.txt", dstFile, Options{
+			Offset:      100,
+			Limit:       1000,
+			Resume:      true,
+			Verify:      true,
+			ChecksumOut: sumFile,
+		})
+		require.NoError(t, err)
+		line, err := os.ReadFile(sumFile)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("%x  %s\n", sha, dstFile), string(line))
+	})
+
+	t.Run("unknown hash", func(t *testing.T) {
+		dstFile := t.TempDir() + "/out.txt"
+		err := CopyWithOptions("testdata/input.txt", dstFile, Options{Verify: true, Hash: "md5"})
+		require.Truef(t, errors.Is(err, ErrUnknownHash), "actual error %q", err)
+		_, err = os.Stat(dstFile)
+		require.Truef(t, errors.Is(err, os.ErrNotExist), "actual error %q", err)
+	})
+}
+
+func TestVerifyChecksumMismatch(t *testing.T) {
+	file, err := os.Open("testdata/out_offset0_limit10.txt")
+	require.NoError(t, err)
+	defer file.Close()
+
+	sum := sha256.Sum256([]byte("0123456789"))
+	err = verifyChecksum(file, 0, 10, HashSHA256, sum[:])
+	require.Truef(t, errors.Is(err, ErrChecksumMismatch), "actual error %q", err)
+	var checksumErr *ChecksumError
+	require.True(t, errors.As(err, &checksumErr))
+	require.Equal(t, HashSHA256, checksumErr.Hash)
+	require.Equal(t, sum[:], checksumErr.Source)
+}
@@ -2,66 +2,361 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"hash"
 	"io"
 	"os"
-
-	"github.com/schollz/progressbar/v3"
+	"path/filepath"
 )
 
 var (
 	ErrUnsupportedFile       = errors.New("unsupported file")
 	ErrOffsetExceedsFileSize = errors.New("offset exceeds file size")
+	ErrResumeMismatch        = errors.New("destination does not match source")
+	ErrIncompatibleOptions   = errors.New("incompatible options")
+	ErrNegativeLimit         = errors.New("limit should be >= 0")
+	ErrNegativeDstOffset     = errors.New("destination offset should be >= 0")
+	ErrPatchExceedsDstSize   = errors.New("patch exceeds destination size")
+)
+
+const (
+	compareBufferSize = 32 * 1024
+	// fastChunkSize - сколько байт копируется одним системным вызовом, чтобы прогресс обновлялся
+	fastChunkSize = 4 << 20
 )
 
+type Options struct {
+	// Offset - отступ в источнике, отрицательный отступ отсчитывается от конца файла
+	Offset int64
+	// Limit - количество копируемых байт, 0 - до конца источника
+	Limit int64
+	// Atomic - писать во временный файл в каталоге назначения и после fsync переименовывать его в toPath
+	Atomic bool
+	// Resume - продолжить копирование с длины существующего toPath, если уже скопированная часть
+	// совпадает с источником
+	Resume bool
+	// Verify - после копирования перечитать dst и сравнить его сумму с суммой прочитанных данных
+	Verify bool
+	// Hash - алгоритм для Verify и ChecksumOut: HashSHA256 (по умолчанию), HashBLAKE2b или HashXXHash
+	Hash string
+	// ChecksumOut - файл для строки в формате sha256sum, "-" - стандартный вывод.
+	// Не поддерживается вместе с Patch
+	ChecksumOut string
+	// Progress получает количество скопированных байт, nil - без отчета о прогрессе
+	Progress ProgressFunc
+	// BandwidthLimit ограничивает скорость копирования в байтах в секунду, 0 - без ограничения
+	BandwidthLimit int64
+	// Patch - записать диапазон источника в существующий toPath с позиции DstOffset без усечения
+	Patch     bool
+	DstOffset int64
+	// KeepSize - не увеличивать toPath в режиме Patch, запись за его конец возвращает ErrPatchExceedsDstSize
+	KeepSize bool
+}
+
 func Copy(fromPath, toPath string, offset, limit int64) error {
-	// Открываем файл только на чтение
-	src, err := os.Open(fromPath)
+	return CopyWithOptions(fromPath, toPath, Options{Offset: offset, Limit: limit})
+}
+
+func CopyWithOptions(fromPath, toPath string, opts Options) error {
+	return copyFile(fromPath, toPath, opts, reportProgress(opts.Progress, newBandwidth(opts.BandwidthLimit)))
+}
+
+func copyFile(fromPath, toPath string, opts Options, progress progressStarter) error {
+	if opts.Atomic && opts.Resume {
+		return fmt.Errorf("%w: atomic and resume", ErrIncompatibleOptions)
+	}
+	if opts.Patch && (opts.Atomic || opts.Resume) {
+		return fmt.Errorf("%w: patch with atomic or resume", ErrIncompatibleOptions)
+	}
+	if opts.Patch && opts.ChecksumOut != "" {
+		// сумма покрывает только записанный диапазон, а не весь toPath
+		return fmt.Errorf("%w: patch and checksum-out", ErrIncompatibleOptions)
+	}
+	if opts.DstOffset < 0 {
+		return ErrNegativeDstOffset
+	}
+	if opts.Hash == "" {
+		opts.Hash = HashSHA256
+	}
+	// Данные хешируются по мере чтения, поэтому копирование идет через память
+	var h hash.Hash
+	if opts.Verify || opts.ChecksumOut != "" {
+		var err error
+		if h, err = newHash(opts.Hash); err != nil {
+			return err
+		}
+	}
+
+	src, err := openSource(fromPath)
 	if err != nil {
 		return err
 	}
 	defer src.Close()
-	stat, err := src.Stat()
+
+	limit, err := src.skip(opts.Offset, opts.Limit)
 	if err != nil {
 		return err
 	}
 
-	if stat.Size() == 0 {
-		return ErrUnsupportedFile
+	var r io.Reader = src
+	if h != nil {
+		r = io.TeeReader(src, h)
 	}
 
-	if stat.Size() < offset {
-		return ErrOffsetExceedsFileSize
+	var dst *destination
+	switch {
+	case opts.Atomic:
+		dst, err = createTemp(toPath)
+	case opts.Resume:
+		dst, err = openResume(toPath, r, limit)
+	case opts.Patch:
+		dst, err = openPatch(toPath, opts.DstOffset, opts.KeepSize, limit)
+	default:
+		dst, err = create(toPath)
 	}
+	if err != nil {
+		return err
+	}
+	defer dst.abort()
 
-	// расчитываем limit с учетем offset до конца файла
-	size := stat.Size() - offset
-	if limit == 0 || limit > size {
-		limit = size
+	// Размер для прогресса - это количество байт, которые будем копировать,
+	// -1 для источника неизвестного размера
+	total := limit
+	if total == 0 {
+		total = -1
+	}
+	report := progress(total, dst.written)
+	// при ограничении скорости копируем в ядре порциями не больше секундного лимита
+	chunk := int64(fastChunkSize)
+	if opts.BandwidthLimit > 0 && opts.BandwidthLimit < chunk {
+		chunk = opts.BandwidthLimit
+	}
+	var w io.Writer = dst.file
+	if dst.room >= 0 {
+		w = &boundedWriter{w: dst.file, room: dst.room}
+	}
+	// Пишем данные в dst файл с учетом лимита
+	var copied int64
+	switch {
+	case src.size >= 0 && h == nil:
+		// для обычного файла копируем в ядре, дыры сохраняем, только если dst после позиции пуст
+		copied, err = copyFast(dst.file, src.file, limit-dst.written, chunk, !opts.Patch, report)
+	case limit == 0:
+		copied, err = io.Copy(io.MultiWriter(w, report), r)
+	default:
+		copied, err = io.CopyN(io.MultiWriter(w, report), r, limit-dst.written)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
 	}
 
-	// Сдвигаем указатель начала файла на offset
-	_, err = src.Seek(offset, io.SeekStart)
-	if err != nil {
+	if opts.Verify {
+		if err := dst.file.Sync(); err != nil {
+			return err
+		}
+		if err := verifyChecksum(dst.file, dst.offset, dst.written+copied, opts.Hash, h.Sum(nil)); err != nil {
+			return err
+		}
+	}
+	if err := dst.commit(); err != nil {
 		return err
 	}
+	if opts.ChecksumOut != "" {
+		return writeChecksum(opts.ChecksumOut, toPath, h.Sum(nil))
+	}
+	return nil
+}
+
+// destination - файл, в который идет запись. При атомарной записи это временный файл,
+// который становится path только в commit.
+type destination struct {
+	file    *os.File
+	path    string
+	atomic  bool
+	done    bool
+	written int64
+	// offset - позиция начала копируемого диапазона в file
+	offset int64
+	// room - сколько байт можно записать, -1 - без ограничения
+	room int64
+}
+
+func create(path string) (*destination, error) {
 	// Создаем пустой dst файл и перезаписываем содержимое
-	dst, err := os.Create(toPath)
+	file, err := os.Create(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer dst.Close()
+	return &destination{file: file, path: path, room: -1}, nil
+}
 
-	// Размер progress-bar - это количество байт, которые будем копировать
-	bar := progressbar.DefaultBytes(
-		limit,
-		"copying",
-	)
+// openPatch открывает path без усечения и ставит позицию записи на offset. При keepSize диапазон
+// известного размера limit должен поместиться в текущий размер файла.
+func openPatch(path string, offset int64, keepSize bool, limit int64) (*destination, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o666)
+	if err != nil {
+		return nil, err
+	}
+	dst := &destination{file: file, path: path, offset: offset, room: -1}
+	if keepSize {
+		stat, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		dst.room = stat.Size() - offset
+		if dst.room < 0 || limit > dst.room {
+			file.Close()
+			return nil, fmt.Errorf("%w: %d bytes at offset %d, destination size %d",
+				ErrPatchExceedsDstSize, limit, offset, stat.Size())
+		}
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return dst, nil
+}
 
-	// Пишем данные в dst файл и в progress bar с учетом лимита
-	_, err = io.CopyN(io.MultiWriter(dst, bar), src, limit)
-	if err != nil && !errors.Is(err, io.EOF) {
+// boundedWriter пишет не больше room байт, чтобы источник неизвестного размера не увеличил dst.
+type boundedWriter struct {
+	w    io.Writer
+	room int64
+}
+
+func (b *boundedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= b.room {
+		n, err := b.w.Write(p)
+		b.room -= int64(n)
+		return n, err
+	}
+	n, err := b.w.Write(p[:b.room])
+	b.room -= int64(n)
+	if err == nil {
+		err = ErrPatchExceedsDstSize
+	}
+	return n, err
+}
+
+func createTemp(path string) (*destination, error) {
+	// Временный файл должен быть в том же каталоге, иначе rename не будет атомарным
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	mode := os.FileMode(0o644)
+	if stat, err := os.Stat(path); err == nil {
+		mode = stat.Mode().Perm()
+	}
+	if err := file.Chmod(mode); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return &destination{file: file, path: path, atomic: true, room: -1}, nil
+}
+
+// openResume открывает существующий path для дозаписи. src должен стоять на начале копируемого
+// диапазона, после сверки он стоит на первом нескопированном байте.
+func openResume(path string, src io.Reader, limit int64) (*destination, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o666)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	written := stat.Size()
+	if limit > 0 && written > limit {
+		file.Close()
+		return nil, fmt.Errorf("%w: destination is larger than copied range", ErrResumeMismatch)
+	}
+	if err := compare(src, file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(written, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &destination{file: file, path: path, written: written, room: -1}, nil
+}
+
+// compare сверяет уже скопированную часть dst с источником.
+func compare(src, dst io.Reader) error {
+	srcBuf := make([]byte, compareBufferSize)
+	dstBuf := make([]byte, compareBufferSize)
+	var pos int64
+	for {
+		n, err := io.ReadFull(dst, dstBuf)
+		if n > 0 {
+			if _, srcErr := io.ReadFull(src, srcBuf[:n]); srcErr != nil {
+				return fmt.Errorf("%w: %s", ErrResumeMismatch, srcErr)
+			}
+			if i := mismatch(srcBuf[:n], dstBuf[:n]); i >= 0 {
+				return fmt.Errorf("%w: at byte %d", ErrResumeMismatch, pos+int64(i))
+			}
+			pos += int64(n)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func mismatch(a, b []byte) int {
+	for i := range a {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+func (d *destination) commit() error {
+	d.done = true
+	if !d.atomic {
+		return d.file.Close()
+	}
+	if err := d.file.Sync(); err != nil {
+		d.file.Close()
+		os.Remove(d.file.Name())
+		return err
+	}
+	if err := d.file.Close(); err != nil {
+		os.Remove(d.file.Name())
+		return err
+	}
+	if err := os.Rename(d.file.Name(), d.path); err != nil {
+		os.Remove(d.file.Name())
 		return err
 	}
+	return syncDir(filepath.Dir(d.path))
+}
 
-	return nil
+// abort закрывает файл, если commit не был вызван. Временный файл удаляется,
+// поэтому при атомарной записи toPath остается нетронутым.
+func (d *destination) abort() {
+	if d.done {
+		return
+	}
+	d.file.Close()
+	if d.atomic {
+		os.Remove(d.file.Name())
+	}
+}
+
+// syncDir сохраняет на диск запись каталога после rename.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
 }
@@ -0,0 +1,144 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyFast копирует n байт с текущей позиции src в текущую позицию dst в ядре через
+// copy_file_range или sendfile порциями по chunkSize байт. Если sparse, дыры src не копируются,
+// а пропускаются в dst, поэтому dst должен быть пустым после своей текущей позиции.
+func copyFast(dst, src *os.File, n, chunkSize int64, sparse bool, report progressFunc) (int64, error) {
+	soff, err := src.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	doff, err := dst.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	start, end := soff, soff+n
+	c := &kernelCopier{dst: dst, src: src, copyRange: true, sendfile: true}
+
+copying:
+	for soff < end {
+		data, hole := soff, end
+		if sparse {
+			data, hole = nextData(src, soff, end)
+		}
+		if data > soff {
+			// дыра в источнике остается дырой в dst
+			report(data - soff)
+			doff += data - soff
+			soff = data
+			continue
+		}
+		for soff < hole {
+			chunk := hole - soff
+			if chunk > chunkSize {
+				chunk = chunkSize
+			}
+			written, err := c.copy(&soff, &doff, chunk)
+			if err != nil {
+				return soff - start, err
+			}
+			if written == 0 {
+				// источник оказался короче, чем при Stat
+				break copying
+			}
+			report(written)
+		}
+	}
+
+	if _, err := src.Seek(soff, io.SeekStart); err != nil {
+		return soff - start, err
+	}
+	if _, err := dst.Seek(doff, io.SeekStart); err != nil {
+		return soff - start, err
+	}
+	// если источник заканчивается дырой, в dst ничего не записано - дотягиваем размер
+	stat, err := dst.Stat()
+	if err != nil {
+		return soff - start, err
+	}
+	if stat.Size() < doff {
+		err = dst.Truncate(doff)
+	}
+	return soff - start, err
+}
+
+// nextData возвращает начало и конец следующего блока данных src в диапазоне [off, end).
+// Если файловая система не сообщает о дырах, весь диапазон считается данными.
+func nextData(src *os.File, off, end int64) (int64, int64) {
+	fd := int(src.Fd())
+	data, err := unix.Seek(fd, off, unix.SEEK_DATA)
+	if errors.Is(err, unix.ENXIO) {
+		// после off только дыра
+		return end, end
+	}
+	if err != nil {
+		return off, end
+	}
+	if data >= end {
+		return end, end
+	}
+	hole, err := unix.Seek(fd, data, unix.SEEK_HOLE)
+	if err != nil || hole > end {
+		hole = end
+	}
+	return data, hole
+}
+
+// kernelCopier переключается на следующий способ, если предыдущий не поддерживается
+// для этой пары файлов: copy_file_range -> sendfile -> чтение и запись.
+type kernelCopier struct {
+	dst, src  *os.File
+	copyRange bool
+	sendfile  bool
+}
+
+func (c *kernelCopier) copy(soff, doff *int64, n int64) (int64, error) {
+	if c.copyRange {
+		written, err := unix.CopyFileRange(int(c.src.Fd()), soff, int(c.dst.Fd()), doff, int(n), 0)
+		if !unsupported(err) {
+			return int64(written), err
+		}
+		c.copyRange = false
+	}
+	if c.sendfile {
+		// sendfile пишет в текущую позицию dst
+		if _, err := c.dst.Seek(*doff, io.SeekStart); err != nil {
+			return 0, err
+		}
+		written, err := unix.Sendfile(int(c.dst.Fd()), int(c.src.Fd()), soff, int(n))
+		if !unsupported(err) {
+			*doff += int64(written)
+			return int64(written), err
+		}
+		c.sendfile = false
+	}
+	if _, err := c.src.Seek(*soff, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := c.dst.Seek(*doff, io.SeekStart); err != nil {
+		return 0, err
+	}
+	written, err := io.CopyN(c.dst, c.src, n)
+	*soff += written
+	*doff += written
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return written, err
+}
+
+func unsupported(err error) bool {
+	return errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EXDEV) ||
+		errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EOPNOTSUPP)
+}
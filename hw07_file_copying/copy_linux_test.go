@@ -0,0 +1,169 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func allocated(t *testing.T, path string) int64 {
+	t.Helper()
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	return stat.Sys().(*syscall.Stat_t).Blocks * 512
+}
+
+func TestCopySparse(t *testing.T) {
+	const size = 16 << 20
+	dir := t.TempDir()
+	srcFile := dir + "/sparse.img"
+	data := bytes.Repeat([]byte("data"), 1024)
+
+	f, err := os.Create(srcFile)
+	require.NoError(t, err)
+	require.NoError(t, f.Truncate(size))
+	_, err = f.WriteAt(data, 0)
+	require.NoError(t, err)
+	_, err = f.WriteAt(data, 8<<20)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	if allocated(t, srcFile) >= size {
+		t.Skip("file system does not support sparse files")
+	}
+	expect, err := os.ReadFile(srcFile)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		offset int64
+		limit  int64
+	}{
+		{name: "whole file"},
+		{name: "starts in hole", offset: 1 << 20, limit: 8 << 20},
+		{name: "ends in hole", offset: 100, limit: 10 << 20},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dstFile := dir + "/out.img"
+			defer os.Remove(dstFile)
+			require.NoError(t, Copy(srcFile, dstFile, tc.offset, tc.limit))
+
+			limit := tc.limit
+			if limit == 0 {
+				limit = size - tc.offset
+			}
+			got, err := os.ReadFile(dstFile)
+			require.NoError(t, err)
+			require.True(t, bytes.Equal(expect[tc.offset:tc.offset+limit], got))
+			require.Truef(t, allocated(t, dstFile) < 1<<20, "destination is not sparse")
+		})
+	}
+}
+
+func TestKernelCopierFallback(t *testing.T) {
+	input, err := os.ReadFile("testdata/input.txt")
+	require.NoError(t, err)
+	src, err := os.Open("testdata/input.txt")
+	require.NoError(t, err)
+	defer src.Close()
+	dst, err := os.Create(t.TempDir() + "/out.txt")
+	require.NoError(t, err)
+	defer dst.Close()
+
+	// начинаем с copy_file_range и по очереди отключаем способы
+	c := &kernelCopier{dst: dst, src: src, copyRange: true, sendfile: true}
+	soff, doff := int64(100), int64(0)
+	for _, step := range []func(){
+		func() {},
+		func() { c.copyRange = false },
+		func() { c.sendfile = false },
+	} {
+		step()
+		written, err := c.copy(&soff, &doff, 1000)
+		require.NoError(t, err)
+		require.Equal(t, int64(1000), written)
+	}
+	require.Equal(t, int64(3100), soff)
+	require.Equal(t, int64(3000), doff)
+
+	got, err := os.ReadFile(dst.Name())
+	require.NoError(t, err)
+	require.Equal(t, string(input[100:3100]), string(got))
+}
+
+func TestCopyTreeXattrs(t *testing.T) {
+	src := t.TempDir() + "/src"
+	require.NoError(t, os.MkdirAll(src, 0o755))
+	require.NoError(t, os.WriteFile(src+"/file.txt", []byte("xattr"), 0o644))
+	if err := unix.Setxattr(src+"/file.txt", "user.origin", []byte("otus"), 0); err != nil {
+		t.Skipf("file system does not support xattrs: %s", err)
+	}
+
+	dst := t.TempDir() + "/dst"
+	require.NoError(t, CopyTree(src, dst, TreeOptions{Preserve: Preserve{Xattrs: true}}))
+	value, err := xattrGet(dst+"/file.txt", "user.origin")
+	require.NoError(t, err)
+	require.Equal(t, "otus", string(value))
+
+	names, err := xattrList(dst + "/file.txt")
+	require.NoError(t, err)
+	require.Contains(t, names, "user.origin")
+}
+
+func TestCopyPatchSparse(t *testing.T) {
+	dir := t.TempDir()
+	srcFile := dir + "/sparse.img"
+	f, err := os.Create(srcFile)
+	require.NoError(t, err)
+	require.NoError(t, f.Truncate(1<<20))
+	_, err = f.WriteAt([]byte("data"), 1<<19)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// дыры источника должны затереть данные dst нулями
+	dstFile := dir + "/out.img"
+	require.NoError(t, os.WriteFile(dstFile, bytes.Repeat([]byte("x"), 2<<20), 0o600))
+	require.NoError(t, CopyWithOptions(srcFile, dstFile, Options{Patch: true, DstOffset: 100, KeepSize: true}))
+
+	expect, err := os.ReadFile(srcFile)
+	require.NoError(t, err)
+	got, err := os.ReadFile(dstFile)
+	require.NoError(t, err)
+	require.Len(t, got, 2<<20)
+	require.True(t, bytes.Equal(expect, got[100:100+1<<20]))
+	require.Equal(t, "xxx", string(got[97:100]))
+	require.Equal(t, byte('x'), got[100+1<<20])
+}
+
+func TestCopyNamedPipe(t *testing.T) {
+	input, err := os.ReadFile("testdata/input.txt")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	fifo := dir + "/fifo"
+	require.NoError(t, syscall.Mkfifo(fifo, 0o600))
+	go func() {
+		w, err := os.OpenFile(fifo, os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		defer w.Close()
+		_, _ = w.Write(input)
+	}()
+
+	// канал читается до EOF, offset пропускается чтением
+	dstFile := dir + "/out.txt"
+	require.NoError(t, Copy(fifo, dstFile, 100, 0))
+	got, err := os.ReadFile(dstFile)
+	require.NoError(t, err)
+	require.Equal(t, string(input[100:]), string(got))
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// copyFast на других системах копирует данные через буфер в памяти, дыры не сохраняются.
+func copyFast(dst, src *os.File, n, _ int64, _ bool, report progressFunc) (int64, error) {
+	written, err := io.CopyN(io.MultiWriter(dst, report), src, n)
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return written, err
+}
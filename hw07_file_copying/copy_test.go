@@ -3,7 +3,9 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -106,3 +108,295 @@ func TestErrCopy(t *testing.T) {
 		})
 	}
 }
+
+func TestCopyAtomic(t *testing.T) {
+	dir := t.TempDir()
+	dstFile := dir + "/out.txt"
+	require.NoError(t, os.WriteFile(dstFile, []byte("old content"), 0o600))
+
+	err := CopyWithOptions("testdata/input.txt", dstFile, Options{Limit: 1000, Atomic: true})
+	require.NoError(t, err)
+
+	expect, err := os.ReadFile("testdata/out_offset0_limit1000.txt")
+	require.NoError(t, err)
+	got, err := os.ReadFile(dstFile)
+	require.NoError(t, err)
+	require.Equal(t, string(expect), string(got))
+
+	// права существующего файла сохраняются, временных файлов не остается
+	stat, err := os.Stat(dstFile)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), stat.Mode().Perm())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	t.Run("error keeps destination", func(t *testing.T) {
+		err := CopyWithOptions("testdata/input.txt", dstFile, Options{Offset: 10000, Atomic: true})
+		require.True(t, errors.Is(err, ErrOffsetExceedsFileSize), "actual error %q", err)
+		got, err := os.ReadFile(dstFile)
+		require.NoError(t, err)
+		require.Equal(t, string(expect), string(got))
+	})
+}
+
+func TestCopyResume(t *testing.T) {
+	expect, err := os.ReadFile("testdata/out_offset100_limit1000.txt")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		partial []byte
+		error   error
+	}{
+		{name: "missing destination"},
+		{name: "empty destination", partial: []byte{}},
+		{name: "partial destination", partial: expect[:300]},
+		{name: "complete destination", partial: expect},
+		{name: "different content", partial: []byte("garbage"), error: ErrResumeMismatch},
+		{name: "larger destination", partial: append(append([]byte{}, expect...), 'x'), error: ErrResumeMismatch},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dstFile := t.TempDir() + "/out.txt"
+			if tc.partial != nil {
+				require.NoError(t, os.WriteFile(dstFile, tc.partial, 0o600))
+			}
+			err := CopyWithOptions("testdata/input.txt", dstFile, Options{Offset: 100, Limit: 1000, Resume: true})
+			got, readErr := os.ReadFile(dstFile)
+			require.NoError(t, readErr)
+			if tc.error != nil {
+				require.Truef(t, errors.Is(err, tc.error), "actual error %q", err)
+				// при несовпадении dst не изменяется
+				require.Equal(t, string(tc.partial), string(got))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, string(expect), string(got))
+		})
+	}
+
+	t.Run("atomic and resume", func(t *testing.T) {
+		err := CopyWithOptions("testdata/input.txt", "/tmp/dst_atomic_resume.txt", Options{Atomic: true, Resume: true})
+		require.True(t, errors.Is(err, ErrIncompatibleOptions), "actual error %q", err)
+	})
+}
+
+func TestCopyNonRegular(t *testing.T) {
+	t.Run("devices", func(t *testing.T) {
+		dstFile := t.TempDir() + "/out.txt"
+		require.NoError(t, Copy("/dev/zero", dstFile, 10, 100))
+		got, err := os.ReadFile(dstFile)
+		require.NoError(t, err)
+		require.Equal(t, make([]byte, 100), got)
+
+		require.NoError(t, Copy("/dev/urandom", dstFile, 0, 1000))
+		stat, err := os.Stat(dstFile)
+		require.NoError(t, err)
+		require.Equal(t, int64(1000), stat.Size())
+	})
+
+	t.Run("proc file", func(t *testing.T) {
+		dstFile := t.TempDir() + "/out.txt"
+		require.NoError(t, Copy("/proc/self/status", dstFile, 0, 5))
+		got, err := os.ReadFile(dstFile)
+		require.NoError(t, err)
+		require.Equal(t, "Name:", string(got))
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		stdin := os.Stdin
+		os.Stdin = r
+		defer func() {
+			os.Stdin = stdin
+			r.Close()
+		}()
+		go func() {
+			defer w.Close()
+			_, _ = io.Copy(w, strings.NewReader("hello, stdin"))
+		}()
+
+		dstFile := t.TempDir() + "/out.txt"
+		require.NoError(t, Copy("-", dstFile, 7, 3))
+		got, err := os.ReadFile(dstFile)
+		require.NoError(t, err)
+		require.Equal(t, "std", string(got))
+	})
+
+	t.Run("offset exceeds stream", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		stdin := os.Stdin
+		os.Stdin = r
+		defer func() {
+			os.Stdin = stdin
+			r.Close()
+		}()
+		w.Close()
+
+		dstFile := t.TempDir() + "/out.txt"
+		err = Copy("-", dstFile, 10, 0)
+		require.Truef(t, errors.Is(err, ErrOffsetExceedsFileSize), "actual error %q", err)
+		_, err = os.Stat(dstFile)
+		require.Truef(t, errors.Is(err, os.ErrNotExist), "actual error %q", err)
+	})
+}
+
+func TestCopyNegativeOffset(t *testing.T) {
+	input, err := os.ReadFile("testdata/input.txt")
+	require.NoError(t, err)
+	size := int64(len(input))
+
+	tests := []struct {
+		name   string
+		offset int64
+		limit  int64
+		expect []byte
+	}{
+		{name: "tail", offset: -1000, expect: input[size-1000:]},
+		{name: "tail with limit", offset: -1000, limit: 10, expect: input[size-1000 : size-990]},
+		{name: "longer than file", offset: -100000, expect: input},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dstFile := t.TempDir() + "/out.txt"
+			require.NoError(t, Copy("testdata/input.txt", dstFile, tc.offset, tc.limit))
+			got, err := os.ReadFile(dstFile)
+			require.NoError(t, err)
+			require.Equal(t, string(tc.expect), string(got))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		dstFile := t.TempDir() + "/out.txt"
+		err := Copy("testdata/input.txt", dstFile, 0, -1)
+		require.Truef(t, errors.Is(err, ErrNegativeLimit), "actual error %q", err)
+		err = Copy("/dev/zero", dstFile, -10, 10)
+		require.Truef(t, errors.Is(err, ErrUnsupportedFile), "actual error %q", err)
+		_, err = os.Stat(dstFile)
+		require.Truef(t, errors.Is(err, os.ErrNotExist), "actual error %q", err)
+	})
+}
+
+func TestCopyPatch(t *testing.T) {
+	input, err := os.ReadFile("testdata/input.txt")
+	require.NoError(t, err)
+	original := []byte("0123456789")
+
+	patched := func(at int, data []byte) string {
+		size := len(original)
+		if at+len(data) > size {
+			size = at + len(data)
+		}
+		expect := make([]byte, size)
+		copy(expect, original)
+		copy(expect[at:], data)
+		return string(expect)
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		// expect - ожидаемое содержимое, пустое при ошибке
+		expect string
+		error  error
+	}{
+		{
+			name:   "inside",
+			opts:   Options{Limit: 4, Patch: true, DstOffset: 3},
+			expect: patched(3, input[:4]),
+		},
+		{
+			name:   "source offset",
+			opts:   Options{Offset: 100, Limit: 4, Patch: true, DstOffset: 3, KeepSize: true},
+			expect: patched(3, input[100:104]),
+		},
+		{
+			name:   "extend",
+			opts:   Options{Limit: 4, Patch: true, DstOffset: 8},
+			expect: patched(8, input[:4]),
+		},
+		{
+			name:   "extend with gap",
+			opts:   Options{Limit: 4, Patch: true, DstOffset: 20},
+			expect: patched(20, input[:4]),
+		},
+		{
+			name:   "verify",
+			opts:   Options{Limit: 4, Patch: true, DstOffset: 5, Verify: true},
+			expect: patched(5, input[:4]),
+		},
+		{
+			name:  "keep size",
+			opts:  Options{Limit: 4, Patch: true, DstOffset: 8, KeepSize: true},
+			error: ErrPatchExceedsDstSize,
+		},
+		{
+			name:  "keep size offset after end",
+			opts:  Options{Limit: 1, Patch: true, DstOffset: 20, KeepSize: true},
+			error: ErrPatchExceedsDstSize,
+		},
+		{
+			name:  "negative offset",
+			opts:  Options{Limit: 1, Patch: true, DstOffset: -1},
+			error: ErrNegativeDstOffset,
+		},
+		{
+			name:  "atomic",
+			opts:  Options{Limit: 1, Patch: true, Atomic: true},
+			error: ErrIncompatibleOptions,
+		},
+		{
+			name:  "checksum out",
+			opts:  Options{Limit: 4, Patch: true, DstOffset: 3, ChecksumOut: "/tmp/dst_patch_checksum.sha256"},
+			error: ErrIncompatibleOptions,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dstFile := t.TempDir() + "/out.bin"
+			require.NoError(t, os.WriteFile(dstFile, original, 0o600))
+			err := CopyWithOptions("testdata/input.txt", dstFile, tc.opts)
+			got, readErr := os.ReadFile(dstFile)
+			require.NoError(t, readErr)
+			if tc.error != nil {
+				require.Truef(t, errors.Is(err, tc.error), "actual error %q", err)
+				require.Equal(t, string(original), string(got))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, string(got))
+		})
+	}
+
+	t.Run("keep size with stream", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		stdin := os.Stdin
+		os.Stdin = r
+		defer func() {
+			os.Stdin = stdin
+			r.Close()
+		}()
+		go func() {
+			defer w.Close()
+			_, _ = w.Write([]byte("abcdef"))
+		}()
+
+		dstFile := t.TempDir() + "/out.bin"
+		require.NoError(t, os.WriteFile(dstFile, original, 0o600))
+		err = CopyWithOptions("-", dstFile, Options{Patch: true, DstOffset: 7, KeepSize: true})
+		require.Truef(t, errors.Is(err, ErrPatchExceedsDstSize), "actual error %q", err)
+		got, err := os.ReadFile(dstFile)
+		require.NoError(t, err)
+		require.Equal(t, "0123456abc", string(got))
+	})
+}
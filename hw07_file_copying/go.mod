@@ -3,6 +3,9 @@ module github.com/alexei38/otus_hw/hw07_file_copying
 go 1.16
 
 require (
+	github.com/cespare/xxhash/v2 v2.1.2
 	github.com/schollz/progressbar/v3 v3.8.5
 	github.com/stretchr/testify v1.3.0
+	golang.org/x/crypto v0.0.0-20211215153901-e495a2d5b3d3
+	golang.org/x/sys v0.0.0-20211216021012-1d35b9e2eb4e
 )
@@ -2,25 +2,138 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strings"
 )
 
 var (
-	from, to      string
-	limit, offset int64
+	from, to       string
+	limit, offset  string
+	byteRange      string
+	atomic, resume bool
+	verify         bool
+	hashName       string
+	checksumOut    string
+	recursive      bool
+	preserve       string
+	follow         bool
+	workers        int
+	bwlimit        string
+	progressMode   string
+	dstOffset      string
+	keepSize       bool
 )
 
 func init() {
-	flag.StringVar(&from, "from", "", "file to read from")
+	flag.StringVar(&from, "from", "", "file to read from, - for stdin")
 	flag.StringVar(&to, "to", "", "file to write to")
-	flag.Int64Var(&limit, "limit", 0, "limit of bytes to copy")
-	flag.Int64Var(&offset, "offset", 0, "offset in input file")
+	flag.StringVar(&limit, "limit", "0", "limit of bytes to copy, accepts suffixes K, M, G, KiB, KB")
+	flag.StringVar(&offset, "offset", "0", "offset in input file, negative offset is counted from the end")
+	flag.StringVar(&byteRange, "range", "", "range to copy instead of offset and limit: start-end, start- or -last")
+	flag.BoolVar(&atomic, "atomic", false, "write to a temporary file and rename it into place")
+	flag.BoolVar(&resume, "resume", false, "continue from the length of an existing destination")
+	flag.BoolVar(&verify, "verify", false, "re-read the destination and compare checksums")
+	flag.StringVar(&hashName, "hash", HashSHA256, "checksum algorithm: sha256, blake2b or xxhash")
+	flag.StringVar(&checksumOut, "checksum-out", "", "file to write a sha256sum-compatible line to, - for stdout")
+	flag.BoolVar(&recursive, "r", false, "copy directories recursively")
+	flag.StringVar(&preserve, "preserve", "", "attributes to preserve in recursive mode: mode,ownership,timestamps,xattr or all")
+	flag.BoolVar(&follow, "L", false, "follow symlinks in recursive mode instead of copying them as links")
+	flag.IntVar(&workers, "workers", 0, "number of files copied in parallel in recursive mode, defaults to the number of CPUs")
+	flag.StringVar(&bwlimit, "bwlimit", "", "bandwidth limit per second, for example 10M")
+	flag.StringVar(&progressMode, "progress", ProgressModeBar, "progress output: bar, json or none")
+	flag.StringVar(&dstOffset, "dst-offset", "", "write into an existing destination at this offset without truncating it")
+	flag.BoolVar(&keepSize, "keep-size", false, "with -dst-offset, fail instead of extending the destination")
 }
 
 func main() {
 	flag.Parse()
-	err := Copy(from, to, offset, limit)
+	progress, finish, err := newReporter(progressMode, os.Stderr)
 	if err != nil {
 		log.Fatalln(err)
 	}
+	start, size, err := parseBounds()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	var patchAt int64
+	if keepSize && dstOffset == "" {
+		log.Fatalln(fmt.Errorf("%w: keep-size without dst-offset", ErrIncompatibleOptions))
+	}
+	if dstOffset != "" {
+		if patchAt, err = parseSize(dstOffset); err != nil {
+			log.Fatalln(fmt.Errorf("dst-offset: %w", err))
+		}
+	}
+	var rate int64
+	if bwlimit != "" {
+		if rate, err = parseSize(strings.TrimSuffix(bwlimit, "/s")); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	opts := Options{
+		Offset:      start,
+		Limit:       size,
+		Atomic:      atomic,
+		Resume:      resume,
+		Verify:      verify,
+		Hash:        hashName,
+		ChecksumOut: checksumOut,
+
+		Progress:       progress,
+		BandwidthLimit: rate,
+
+		Patch:     dstOffset != "",
+		DstOffset: patchAt,
+		KeepSize:  keepSize,
+	}
+	if !recursive {
+		err = CopyWithOptions(from, to, opts)
+		finish()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	attrs, err := ParsePreserve(preserve)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	err = CopyTree(from, to, TreeOptions{
+		Options:        opts,
+		Preserve:       attrs,
+		FollowSymlinks: follow,
+		Workers:        workers,
+	})
+	finish()
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// parseBounds возвращает offset и limit из -offset и -limit или из -range.
+func parseBounds() (int64, int64, error) {
+	if byteRange != "" {
+		var err error
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "offset" || f.Name == "limit" {
+				err = fmt.Errorf("%w: range with %s", ErrIncompatibleOptions, f.Name)
+			}
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+		return parseRange(byteRange)
+	}
+	start, err := parseOffset(offset)
+	if err != nil {
+		return 0, 0, fmt.Errorf("offset: %w", err)
+	}
+	size, err := parseSize(limit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("limit: %w", err)
+	}
+	return start, size, nil
 }
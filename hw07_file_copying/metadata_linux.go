@@ -0,0 +1,86 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func preserveOwner(path string, info os.FileInfo) error {
+	stat := info.Sys().(*syscall.Stat_t)
+	return os.Lchown(path, int(stat.Uid), int(stat.Gid))
+}
+
+// preserveTimes переносит atime и mtime, для ссылок меняется время самой ссылки.
+func preserveTimes(path string, info os.FileInfo) error {
+	stat := info.Sys().(*syscall.Stat_t)
+	times := []unix.Timespec{
+		unix.NsecToTimespec(syscall.TimespecToNsec(stat.Atim)),
+		unix.NsecToTimespec(info.ModTime().UnixNano()),
+	}
+	return unix.UtimesNanoAt(unix.AT_FDCWD, path, times, unix.AT_SYMLINK_NOFOLLOW)
+}
+
+func preserveXattrs(src, dst string, info os.FileInfo) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		// пользовательские атрибуты у ссылок в Linux не поддерживаются
+		return nil
+	}
+	names, err := xattrList(src)
+	if errors.Is(err, unix.ENOTSUP) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		value, err := xattrGet(src, name)
+		if err != nil {
+			return err
+		}
+		err = unix.Setxattr(dst, name, value, 0)
+		if err != nil && !errors.Is(err, unix.ENOTSUP) {
+			return err
+		}
+	}
+	return nil
+}
+
+func xattrList(path string) ([]string, error) {
+	size, err := unix.Listxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	size, err = unix.Listxattr(path, buf)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	start := 0
+	// имена разделены нулевыми байтами
+	for i, b := range buf[:size] {
+		if b == 0 {
+			if i > start {
+				names = append(names, string(buf[start:i]))
+			}
+			start = i + 1
+		}
+	}
+	return names, nil
+}
+
+func xattrGet(path, name string) ([]byte, error) {
+	size, err := unix.Getxattr(path, name, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	value := make([]byte, size)
+	size, err = unix.Getxattr(path, name, value)
+	return value[:size], err
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import (
+	"os"
+)
+
+// preserveOwner на других системах не поддерживается.
+func preserveOwner(string, os.FileInfo) error {
+	return nil
+}
+
+// preserveTimes переносит только mtime, время ссылок не меняется.
+func preserveTimes(path string, info os.FileInfo) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+	return os.Chtimes(path, info.ModTime(), info.ModTime())
+}
+
+// preserveXattrs на других системах не поддерживается.
+func preserveXattrs(string, string, os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ProgressFunc получает количество скопированных байт и размер копируемого диапазона,
+// total = -1, если размер неизвестен. Вызывается из копирующей горутины.
+type ProgressFunc func(done, total int64)
+
+// progressFunc получает количество байт, скопированных с прошлого вызова.
+type progressFunc func(n int64)
+
+func (f progressFunc) Write(p []byte) (int, error) {
+	f(int64(len(p)))
+	return len(p), nil
+}
+
+// progressStarter получает размер копируемого диапазона и размер уже скопированной
+// при Resume части и возвращает функцию учета скопированных байт.
+type progressStarter func(total, done int64) progressFunc
+
+// reportProgress передает прогресс копирования одного файла в fn и ограничивает скорость.
+func reportProgress(fn ProgressFunc, limiter *bandwidth) progressStarter {
+	return func(total, done int64) progressFunc {
+		if fn != nil {
+			fn(done, total)
+		}
+		return func(n int64) {
+			done += n
+			if fn != nil {
+				fn(done, total)
+			}
+			limiter.wait(n)
+		}
+	}
+}
+
+// bandwidth ограничивает скорость копирования: wait задерживает копирование, пока средняя
+// скорость с начала копирования выше rate. Общий bandwidth можно использовать из нескольких горутин.
+type bandwidth struct {
+	mu    sync.Mutex
+	rate  int64
+	start time.Time
+	bytes int64
+}
+
+func newBandwidth(rate int64) *bandwidth {
+	if rate <= 0 {
+		return nil
+	}
+	return &bandwidth{rate: rate, start: time.Now()}
+}
+
+func (b *bandwidth) wait(n int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.bytes += n
+	due := b.start.Add(time.Duration(float64(b.bytes) / float64(b.rate) * float64(time.Second)))
+	b.mu.Unlock()
+	time.Sleep(time.Until(due))
+}
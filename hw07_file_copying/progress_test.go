@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type progressCall struct {
+	done, total int64
+}
+
+func TestCopyProgress(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		calls := make([]progressCall, 0)
+		err := CopyWithOptions("testdata/input.txt", t.TempDir()+"/out.txt", Options{
+			Offset: 100,
+			Limit:  1000,
+			Progress: func(done, total int64) {
+				calls = append(calls, progressCall{done: done, total: total})
+			},
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, calls)
+		require.Equal(t, progressCall{done: 0, total: 1000}, calls[0])
+		require.Equal(t, progressCall{done: 1000, total: 1000}, calls[len(calls)-1])
+		for i := 1; i < len(calls); i++ {
+			require.True(t, calls[i].done >= calls[i-1].done)
+		}
+	})
+
+	t.Run("resume starts from destination length", func(t *testing.T) {
+		expect, err := os.ReadFile("testdata/out_offset0_limit1000.txt")
+		require.NoError(t, err)
+		dstFile := t.TempDir() + "/out.txt"
+		require.NoError(t, os.WriteFile(dstFile, expect[:400], 0o600))
+
+		var first progressCall
+		err = CopyWithOptions("testdata/input.txt", dstFile, Options{
+			Limit:  1000,
+			Resume: true,
+			Progress: func(done, total int64) {
+				if first.total == 0 {
+					first = progressCall{done: done, total: total}
+				}
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, progressCall{done: 400, total: 1000}, first)
+	})
+
+	t.Run("tree", func(t *testing.T) {
+		src := makeTree(t)
+		var last progressCall
+		err := CopyTree(src, t.TempDir()+"/dst", TreeOptions{
+			Options: Options{Progress: func(done, total int64) {
+				last = progressCall{done: done, total: total}
+			}},
+			Workers: 2,
+		})
+		require.NoError(t, err)
+		total := int64(len("nested file") + len("top level file"))
+		require.Equal(t, progressCall{done: total, total: total}, last)
+	})
+}
+
+func TestCopyBandwidthLimit(t *testing.T) {
+	start := time.Now()
+	err := CopyWithOptions("testdata/input.txt", t.TempDir()+"/out.txt", Options{
+		Limit:          4000,
+		BandwidthLimit: 10000,
+	})
+	require.NoError(t, err)
+	require.True(t, time.Since(start) >= 350*time.Millisecond, "copied too fast: %s", time.Since(start))
+}
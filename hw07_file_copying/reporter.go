@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+var ErrUnknownProgress = errors.New("unknown progress mode")
+
+const (
+	ProgressModeBar  = "bar"
+	ProgressModeJSON = "json"
+	ProgressModeNone = "none"
+)
+
+// jsonProgressInterval - как часто выводится строка прогресса в режиме json.
+const jsonProgressInterval = time.Second
+
+// newReporter возвращает функцию вывода прогресса в выбранном режиме и функцию,
+// которую нужно вызвать после копирования.
+func newReporter(mode string, w io.Writer) (ProgressFunc, func(), error) {
+	switch mode {
+	case ProgressModeBar:
+		var bar *progressbar.ProgressBar
+		return func(done, total int64) {
+			if bar == nil {
+				bar = newBytesBar(total, w)
+			}
+			_ = bar.Set64(done)
+		}, func() {}, nil
+	case ProgressModeJSON:
+		r := &jsonReporter{w: w, interval: jsonProgressInterval, start: time.Now()}
+		return r.report, r.finish, nil
+	case ProgressModeNone:
+		return nil, func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnknownProgress, mode)
+	}
+}
+
+// newBytesBar повторяет progressbar.DefaultBytes, но пишет все, включая перевод строки
+// в конце, в w, а не в stdout, где может быть вывод контрольной суммы.
+func newBytesBar(total int64, w io.Writer) *progressbar.ProgressBar {
+	bar := progressbar.NewOptions64(
+		total,
+		progressbar.OptionSetDescription("copying"),
+		progressbar.OptionSetWriter(w),
+		progressbar.OptionShowBytes(true),
+		progressbar.OptionSetWidth(10),
+		progressbar.OptionThrottle(65*time.Millisecond),
+		progressbar.OptionShowCount(),
+		progressbar.OptionOnCompletion(func() {
+			fmt.Fprintln(w)
+		}),
+		progressbar.OptionSpinnerType(14),
+		progressbar.OptionFullWidth(),
+	)
+	_ = bar.RenderBlank()
+	return bar
+}
+
+type progressLine struct {
+	Done  int64 `json:"bytes_done"`
+	Total int64 `json:"bytes_total"`
+	// Rate - средняя скорость в байтах в секунду
+	Rate float64 `json:"rate"`
+	// ETA в секундах, -1, если размер неизвестен
+	ETA float64 `json:"eta_seconds"`
+}
+
+// jsonReporter выводит прогресс строками JSON не чаще, чем раз в interval.
+type jsonReporter struct {
+	w        io.Writer
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+	done     int64
+	total    int64
+	written  bool
+}
+
+func (r *jsonReporter) report(done, total int64) {
+	r.done, r.total = done, total
+	r.written = false
+	now := time.Now()
+	if now.Sub(r.last) < r.interval && done != total {
+		return
+	}
+	r.last = now
+	r.write(now)
+}
+
+func (r *jsonReporter) finish() {
+	if !r.written {
+		r.write(time.Now())
+	}
+}
+
+func (r *jsonReporter) write(now time.Time) {
+	line := progressLine{Done: r.done, Total: r.total, ETA: -1}
+	if elapsed := now.Sub(r.start).Seconds(); elapsed > 0 {
+		line.Rate = float64(r.done) / elapsed
+	}
+	if r.total >= 0 && line.Rate > 0 {
+		line.ETA = float64(r.total-r.done) / line.Rate
+	}
+	// ошибка вывода прогресса не должна прерывать копирование
+	_ = json.NewEncoder(r.w).Encode(line)
+	r.written = true
+}
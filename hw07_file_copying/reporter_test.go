@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONReporter(t *testing.T) {
+	out := &bytes.Buffer{}
+	r := &jsonReporter{w: out, interval: time.Hour, start: time.Now().Add(-time.Second)}
+	r.report(0, 1000)
+	// промежуточные значения реже interval не выводятся
+	r.report(500, 1000)
+	r.report(1000, 1000)
+	r.finish()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	var first, last progressLine
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &last))
+	require.Equal(t, int64(0), first.Done)
+	require.Equal(t, float64(-1), first.ETA)
+	require.Equal(t, int64(1000), last.Done)
+	require.Equal(t, int64(1000), last.Total)
+	require.True(t, last.Rate > 0)
+	require.Equal(t, float64(0), last.ETA)
+
+	t.Run("finish writes last state", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		r := &jsonReporter{w: out, interval: time.Hour, start: time.Now()}
+		r.report(0, -1)
+		r.report(100, -1)
+		r.finish()
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 2)
+		require.Contains(t, lines[1], `"bytes_done":100`)
+	})
+}
+
+func TestNewReporter(t *testing.T) {
+	for _, mode := range []string{ProgressModeBar, ProgressModeJSON, ProgressModeNone} {
+		_, finish, err := newReporter(mode, &bytes.Buffer{})
+		require.NoError(t, err)
+		finish()
+	}
+	_, _, err := newReporter("xml", &bytes.Buffer{})
+	require.True(t, errors.Is(err, ErrUnknownProgress))
+
+	t.Run("bar writes to w", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		progress, finish, err := newReporter(ProgressModeBar, out)
+		require.NoError(t, err)
+		progress(0, 100)
+		progress(100, 100)
+		finish()
+		require.Contains(t, out.String(), "copying")
+		// перевод строки после завершения тоже уходит в w, а не в stdout
+		require.True(t, strings.HasSuffix(out.String(), "\n"), "actual output - %q", out.String())
+	})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidSize = errors.New("invalid size")
+
+// sizeSuffixes - множители как в dd: K, KiB - 1024, KB - 1000.
+var sizeSuffixes = map[string]int64{
+	"":    1,
+	"B":   1,
+	"K":   1 << 10,
+	"KIB": 1 << 10,
+	"KB":  1000,
+	"M":   1 << 20,
+	"MIB": 1 << 20,
+	"MB":  1000 * 1000,
+	"G":   1 << 30,
+	"GIB": 1 << 30,
+	"GB":  1000 * 1000 * 1000,
+	"T":   1 << 40,
+	"TIB": 1 << 40,
+	"TB":  1000 * 1000 * 1000 * 1000,
+}
+
+// parseSize разбирает неотрицательный размер в байтах с необязательным суффиксом, например, 10M или 1.5GiB.
+// Дробная часть допускается только с суффиксом и только если размер получается целым.
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	multiplier, ok := sizeSuffixes[strings.ToUpper(s[i:])]
+	if !ok || i == 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, s)
+	}
+	if !strings.Contains(s[:i], ".") {
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil || n > (1<<63-1)/multiplier {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidSize, s)
+		}
+		return n * multiplier, nil
+	}
+	// дробное значение допустимо только с множителем и если получается целое число байт,
+	// считаем точно, чтобы 1.1KB не превратилось в 1100.0000000000002
+	f, ok := new(big.Rat).SetString(s[:i])
+	if !ok || multiplier == 1 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, s)
+	}
+	f.Mul(f, new(big.Rat).SetInt64(multiplier))
+	if !f.IsInt() || !f.Num().IsInt64() {
+		return 0, fmt.Errorf("%w: %q is not a whole number of bytes", ErrInvalidSize, s)
+	}
+	return f.Num().Int64(), nil
+}
+
+var ErrInvalidRange = errors.New("invalid range")
+
+// parseOffset разбирает смещение с суффиксом размера, отрицательное смещение считается от конца файла.
+func parseOffset(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "-") {
+		n, err := parseSize(s[1:])
+		return -n, err
+	}
+	return parseSize(s)
+}
+
+// parseRange разбирает диапазон в offset и limit: start-end (end не включается),
+// start- (до конца файла) или -n (последние n байт).
+func parseRange(s string) (offset, limit int64, err error) {
+	s = strings.TrimSpace(s)
+	i := strings.Index(s, "-")
+	if i < 0 {
+		return 0, 0, fmt.Errorf("%w: %q, expected start-end", ErrInvalidRange, s)
+	}
+	if i == 0 {
+		n, err := parseSize(s[1:])
+		if err != nil {
+			return 0, 0, fmt.Errorf("%w: %s", ErrInvalidRange, err)
+		}
+		if n == 0 {
+			return 0, 0, fmt.Errorf("%w: %q is empty", ErrInvalidRange, s)
+		}
+		return -n, 0, nil
+	}
+
+	start, err := parseSize(s[:i])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %s", ErrInvalidRange, err)
+	}
+	if s[i+1:] == "" {
+		return start, 0, nil
+	}
+	end, err := parseSize(s[i+1:])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %s", ErrInvalidRange, err)
+	}
+	if end <= start {
+		return 0, 0, fmt.Errorf("%w: %q, end should be greater than start", ErrInvalidRange, s)
+	}
+	return start, end - start, nil
+}
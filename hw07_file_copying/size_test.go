@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect int64
+		error  error
+	}{
+		{input: "0", expect: 0},
+		{input: "1024", expect: 1024},
+		{input: "100B", expect: 100},
+		{input: "10K", expect: 10 << 10},
+		{input: "10k", expect: 10 << 10},
+		{input: "10KiB", expect: 10 << 10},
+		{input: "10KB", expect: 10000},
+		{input: "1.5M", expect: 3 << 19},
+		{input: "1.5K", expect: 1536},
+		{input: "1.1KB", expect: 1100},
+		{input: "0.5KiB", expect: 512},
+		{input: "2G", expect: 2 << 30},
+		{input: "1GB", expect: 1000 * 1000 * 1000},
+		{input: "1T", expect: 1 << 40},
+		{input: "", error: ErrInvalidSize},
+		{input: "M", error: ErrInvalidSize},
+		{input: "-1", error: ErrInvalidSize},
+		{input: "10X", error: ErrInvalidSize},
+		{input: "1.2.3K", error: ErrInvalidSize},
+		{input: "1.5", error: ErrInvalidSize},
+		{input: "0.9", error: ErrInvalidSize},
+		{input: "2.0B", error: ErrInvalidSize},
+		{input: "0.1K", error: ErrInvalidSize},
+		{input: "1.0000001KB", error: ErrInvalidSize},
+		{input: "9999999.5T", error: ErrInvalidSize},
+		{input: "99999999999T", error: ErrInvalidSize},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := parseSize(tc.input)
+			if tc.error != nil {
+				require.Truef(t, errors.Is(err, tc.error), "actual error %q", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, got)
+		})
+	}
+}
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect int64
+		error  error
+	}{
+		{input: "100", expect: 100},
+		{input: "1K", expect: 1024},
+		{input: "-10M", expect: -10 << 20},
+		{input: "-", error: ErrInvalidSize},
+		{input: "--1", error: ErrInvalidSize},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := parseOffset(tc.input)
+			if tc.error != nil {
+				require.Truef(t, errors.Is(err, tc.error), "actual error %q", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, got)
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		input  string
+		offset int64
+		limit  int64
+		error  error
+	}{
+		{input: "100-1100", offset: 100, limit: 1000},
+		{input: "1G-2G", offset: 1 << 30, limit: 1 << 30},
+		{input: "6000-", offset: 6000, limit: 0},
+		{input: "-10M", offset: -10 << 20, limit: 0},
+		{input: "100", error: ErrInvalidRange},
+		{input: "200-100", error: ErrInvalidRange},
+		{input: "100-100", error: ErrInvalidRange},
+		{input: "-0", error: ErrInvalidRange},
+		{input: "a-b", error: ErrInvalidRange},
+		{input: "1-2-3", error: ErrInvalidRange},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			offset, limit, err := parseRange(tc.input)
+			if tc.error != nil {
+				require.Truef(t, errors.Is(err, tc.error), "actual error %q", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.offset, offset)
+			require.Equal(t, tc.limit, limit)
+		})
+	}
+}
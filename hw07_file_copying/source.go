@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// stdinPath - значение fromPath для чтения из стандартного ввода.
+const stdinPath = "-"
+
+type source struct {
+	file *os.File
+	// size - размер файла или -1, если его нельзя узнать заранее (каналы, устройства, файлы /proc)
+	size int64
+	// untilEOF - источник заканчивается сам, поэтому без limit его можно читать до EOF
+	untilEOF bool
+}
+
+func openSource(path string) (*source, error) {
+	file := os.Stdin
+	if path != stdinPath {
+		var err error
+		// Открываем файл только на чтение
+		if file, err = os.Open(path); err != nil {
+			return nil, err
+		}
+	}
+	src := &source{file: file, size: -1}
+	stat, err := file.Stat()
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	mode := stat.Mode()
+	switch {
+	case mode.IsRegular() && stat.Size() > 0:
+		src.size = stat.Size()
+	case mode&(os.ModeNamedPipe|os.ModeSocket) != 0:
+		src.untilEOF = true
+	}
+	return src, nil
+}
+
+func (s *source) Read(p []byte) (int, error) {
+	return s.file.Read(p)
+}
+
+func (s *source) Close() error {
+	if s.file == os.Stdin {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// skip сдвигает источник на offset и возвращает количество байт для копирования, 0 - до EOF.
+// Отрицательный offset отсчитывается от конца файла.
+func (s *source) skip(offset, limit int64) (int64, error) {
+	if limit < 0 {
+		return 0, ErrNegativeLimit
+	}
+	if offset < 0 {
+		if s.size < 0 {
+			return 0, fmt.Errorf("%w: negative offset needs a file of known size", ErrUnsupportedFile)
+		}
+		// как tail -c: если файл короче, копируем его целиком
+		offset += s.size
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	if s.size < 0 {
+		// /dev/zero и подобные без limit никогда не закончатся
+		if limit == 0 && !s.untilEOF {
+			return 0, ErrUnsupportedFile
+		}
+		// неперематываемый источник сдвигаем чтением
+		_, err := io.CopyN(io.Discard, s.file, offset)
+		if errors.Is(err, io.EOF) {
+			return 0, ErrOffsetExceedsFileSize
+		}
+		return limit, err
+	}
+
+	if s.size < offset {
+		return 0, ErrOffsetExceedsFileSize
+	}
+
+	// расчитываем limit с учетем offset до конца файла
+	size := s.size - offset
+	if limit == 0 || limit > size {
+		limit = size
+	}
+
+	// Сдвигаем указатель начала файла на offset
+	_, err := s.file.Seek(offset, io.SeekStart)
+	return limit, err
+}
@@ -21,5 +21,38 @@ cmp out.txt testdata/out_offset100_limit1000.txt
 ./go-cp -from testdata/input.txt -to out.txt -offset 6000 -limit 1000
 cmp out.txt testdata/out_offset6000_limit1000.txt
 
-rm -f go-cp out.txt
+./go-cp -from testdata/input.txt -to out.txt -offset 100 -limit 1000 -atomic
+cmp out.txt testdata/out_offset100_limit1000.txt
+
+head -c 300 testdata/out_offset100_limit1000.txt > out.txt
+./go-cp -from testdata/input.txt -to out.txt -offset 100 -limit 1000 -resume
+cmp out.txt testdata/out_offset100_limit1000.txt
+
+./go-cp -from - -to out.txt -offset 100 -limit 1000 < <(cat testdata/input.txt)
+cmp out.txt testdata/out_offset100_limit1000.txt
+
+./go-cp -from testdata/input.txt -to out.txt -verify -checksum-out out.txt.sha256
+sha256sum -c out.txt.sha256
+
+./go-cp -r -from testdata -to out -preserve mode,timestamps -workers 2
+diff -r out testdata
+
+./go-cp -from testdata/input.txt -to out.txt -bwlimit 64K -progress json 2> progress.json
+cmp out.txt testdata/out_offset0_limit0.txt
+tail -n 1 progress.json | grep -q '"bytes_done":6617'
+
+./go-cp -from testdata/input.txt -to out.txt -range 100-1100
+cmp out.txt testdata/out_offset100_limit1000.txt
+
+./go-cp -from testdata/input.txt -to out.txt -offset -617
+cmp out.txt testdata/out_offset6000_limit1000.txt
+
+./go-cp -from testdata/input.txt -to out.txt -limit 100
+if ./go-cp -from testdata/input.txt -to out.txt -offset 100 -limit 900 -dst-offset 100 -keep-size; then
+  exit 1
+fi
+./go-cp -from testdata/input.txt -to out.txt -offset 100 -limit 900 -dst-offset 100
+cmp out.txt testdata/out_offset0_limit1000.txt
+
+rm -rf go-cp progress.json out out.txt out.txt.sha256
 echo "PASS"
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrRecursiveRange  = errors.New("offset and limit are not supported in recursive mode")
+	ErrSymlinkLoop     = errors.New("symlink loop")
+	ErrUnknownPreserve = errors.New("unknown preserve attribute")
+)
+
+// Preserve - какие метаданные переносятся при копировании дерева.
+type Preserve struct {
+	Mode   bool
+	Owner  bool
+	Times  bool
+	Xattrs bool
+}
+
+// ParsePreserve разбирает список через запятую: mode, ownership, timestamps, xattr или all.
+func ParsePreserve(s string) (Preserve, error) {
+	var p Preserve
+	if s == "" {
+		return p, nil
+	}
+	for _, attr := range strings.Split(s, ",") {
+		switch strings.TrimSpace(attr) {
+		case "mode":
+			p.Mode = true
+		case "ownership":
+			p.Owner = true
+		case "timestamps":
+			p.Times = true
+		case "xattr":
+			p.Xattrs = true
+		case "all":
+			p = Preserve{Mode: true, Owner: true, Times: true, Xattrs: true}
+		default:
+			return Preserve{}, fmt.Errorf("%w: %q", ErrUnknownPreserve, attr)
+		}
+	}
+	return p, nil
+}
+
+type TreeOptions struct {
+	// Options применяются к каждому файлу, Offset, Limit, ChecksumOut и Patch не поддерживаются
+	Options
+	Preserve Preserve
+	// FollowSymlinks - копировать содержимое, на которое указывают ссылки, а не сами ссылки
+	FollowSymlinks bool
+	// Workers - сколько файлов копируется одновременно, по умолчанию - число CPU
+	Workers int
+}
+
+type treeEntry struct {
+	src, dst string
+	info     os.FileInfo
+}
+
+type tree struct {
+	follow bool
+	dirs   []treeEntry
+	files  []treeEntry
+	links  []treeEntry
+	size   int64
+}
+
+// CopyTree копирует каталог fromDir в toDir: сначала создаются каталоги и ссылки, затем файлы
+// копируются в opts.Workers горутинах, в конце переносятся метаданные каталогов.
+func CopyTree(fromDir, toDir string, opts TreeOptions) error {
+	if opts.Offset != 0 || opts.Limit != 0 {
+		return ErrRecursiveRange
+	}
+	if opts.ChecksumOut != "" {
+		return fmt.Errorf("%w: recursive and checksum-out", ErrIncompatibleOptions)
+	}
+	if opts.Patch {
+		return fmt.Errorf("%w: recursive and patch", ErrIncompatibleOptions)
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	info, err := os.Stat(fromDir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s is not a directory", ErrUnsupportedFile, fromDir)
+	}
+
+	// Сначала обходим источник целиком, чтобы toDir внутри fromDir не попал в обход
+	t := &tree{follow: opts.FollowSymlinks}
+	if err := t.walk(treeEntry{src: fromDir, dst: toDir, info: info}, nil); err != nil {
+		return err
+	}
+	for _, dir := range t.dirs {
+		// права каталога выставляются в конце, пока в него нужно писать
+		if err := os.MkdirAll(dir.dst, 0o755); err != nil {
+			return err
+		}
+	}
+	for _, link := range t.links {
+		if err := copySymlink(link, opts.Preserve); err != nil {
+			return err
+		}
+	}
+
+	if err := t.copyFiles(opts, t.progress(opts.Progress, newBandwidth(opts.BandwidthLimit))); err != nil {
+		return err
+	}
+
+	// вложенные каталоги идут после родителя, поэтому обходим с конца
+	for i := len(t.dirs) - 1; i >= 0; i-- {
+		if err := preserveMeta(t.dirs[i], opts.Preserve); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *tree) walk(dir treeEntry, parents []os.FileInfo) error {
+	for _, parent := range parents {
+		if os.SameFile(parent, dir.info) {
+			return fmt.Errorf("%w: %s", ErrSymlinkLoop, dir.src)
+		}
+	}
+	t.dirs = append(t.dirs, dir)
+	parents = append(parents, dir.info)
+
+	entries, err := os.ReadDir(dir.src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		entry := treeEntry{
+			src: filepath.Join(dir.src, e.Name()),
+			dst: filepath.Join(dir.dst, e.Name()),
+		}
+		if t.follow {
+			entry.info, err = os.Stat(entry.src)
+		} else {
+			entry.info, err = os.Lstat(entry.src)
+		}
+		if err != nil {
+			return err
+		}
+		mode := entry.info.Mode()
+		switch {
+		case mode.IsDir():
+			if err := t.walk(entry, parents); err != nil {
+				return err
+			}
+		case mode&os.ModeSymlink != 0:
+			t.links = append(t.links, entry)
+		case mode.IsRegular():
+			t.files = append(t.files, entry)
+			t.size += entry.info.Size()
+		default:
+			// каналы и устройства в дереве не копируем, чтение из них может не закончиться
+			return fmt.Errorf("%w: %s", ErrUnsupportedFile, entry.src)
+		}
+	}
+	return nil
+}
+
+// progress суммирует прогресс всех файлов дерева, лимит скорости общий для всех воркеров.
+func (t *tree) progress(fn ProgressFunc, limiter *bandwidth) progressStarter {
+	mu := sync.Mutex{}
+	var done int64
+	add := func(n int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		done += n
+		if fn != nil {
+			fn(done, t.size)
+		}
+	}
+	return func(_, resumed int64) progressFunc {
+		add(resumed)
+		return func(n int64) {
+			add(n)
+			limiter.wait(n)
+		}
+	}
+}
+
+// copyFiles копирует файлы в opts.Workers горутинах и останавливается на первой ошибке.
+func (t *tree) copyFiles(opts TreeOptions, progress progressStarter) error {
+	jobs := make(chan treeEntry)
+	mu := sync.Mutex{}
+	var firstErr error
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				if err := copyTreeFile(file, opts, progress); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("%s: %w", file.src, err)
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, file := range t.files {
+		if failed() {
+			break
+		}
+		jobs <- file
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
+
+func copyTreeFile(file treeEntry, opts TreeOptions, progress progressStarter) error {
+	if file.info.Size() == 0 {
+		// Copy не принимает пустые файлы, в дереве они создаются как есть
+		f, err := os.Create(file.dst)
+		if err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	} else if err := copyFile(file.src, file.dst, opts.Options, progress); err != nil {
+		return err
+	}
+	return preserveMeta(file, opts.Preserve)
+}
+
+func copySymlink(link treeEntry, preserve Preserve) error {
+	target, err := os.Readlink(link.src)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(link.dst); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Symlink(target, link.dst); err != nil {
+		return err
+	}
+	// права у ссылок не используются
+	preserve.Mode = false
+	return preserveMeta(link, preserve)
+}
+
+func preserveMeta(entry treeEntry, preserve Preserve) error {
+	if preserve.Owner {
+		if err := preserveOwner(entry.dst, entry.info); err != nil {
+			return err
+		}
+	}
+	if preserve.Xattrs {
+		if err := preserveXattrs(entry.src, entry.dst, entry.info); err != nil {
+			return err
+		}
+	}
+	if preserve.Mode {
+		// chmod после chown, иначе chown сбрасывает setuid и setgid
+		if err := os.Chmod(entry.dst, entry.info.Mode()&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+			return err
+		}
+	}
+	if preserve.Times {
+		return preserveTimes(entry.dst, entry.info)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func makeTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir() + "/src"
+	require.NoError(t, os.MkdirAll(root+"/a/b", 0o755))
+	require.NoError(t, os.WriteFile(root+"/a/b/file.txt", []byte("nested file"), 0o600))
+	require.NoError(t, os.WriteFile(root+"/a/empty.txt", nil, 0o644))
+	require.NoError(t, os.WriteFile(root+"/top.txt", []byte("top level file"), 0o640))
+	require.NoError(t, os.Symlink("a/b/file.txt", root+"/link.txt"))
+	require.NoError(t, os.Chmod(root+"/a/b", 0o750))
+
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, path := range []string{"/a/b/file.txt", "/top.txt", "/a/b", "/a"} {
+		require.NoError(t, os.Chtimes(root+path, mtime, mtime))
+	}
+	return root
+}
+
+func TestCopyTree(t *testing.T) {
+	src := makeTree(t)
+
+	t.Run("preserve all", func(t *testing.T) {
+		dst := t.TempDir() + "/dst"
+		err := CopyTree(src, dst, TreeOptions{
+			Preserve: Preserve{Mode: true, Owner: true, Times: true, Xattrs: true},
+			Workers:  2,
+		})
+		require.NoError(t, err)
+
+		for _, path := range []string{"/a/b/file.txt", "/a/empty.txt", "/top.txt"} {
+			expect, err := os.ReadFile(src + path)
+			require.NoError(t, err)
+			got, err := os.ReadFile(dst + path)
+			require.NoError(t, err)
+			require.Equal(t, string(expect), string(got), path)
+		}
+		for _, path := range []string{"/a/b/file.txt", "/top.txt", "/a/b", "/a"} {
+			expect, err := os.Stat(src + path)
+			require.NoError(t, err)
+			got, err := os.Stat(dst + path)
+			require.NoError(t, err)
+			require.Equal(t, expect.Mode(), got.Mode(), path)
+			require.True(t, expect.ModTime().Equal(got.ModTime()), path)
+		}
+
+		target, err := os.Readlink(dst + "/link.txt")
+		require.NoError(t, err)
+		require.Equal(t, "a/b/file.txt", target)
+	})
+
+	t.Run("follow symlinks", func(t *testing.T) {
+		dst := t.TempDir() + "/dst"
+		require.NoError(t, CopyTree(src, dst, TreeOptions{FollowSymlinks: true}))
+		info, err := os.Lstat(dst + "/link.txt")
+		require.NoError(t, err)
+		require.True(t, info.Mode().IsRegular())
+		got, err := os.ReadFile(dst + "/link.txt")
+		require.NoError(t, err)
+		require.Equal(t, "nested file", string(got))
+	})
+
+	t.Run("symlink loop", func(t *testing.T) {
+		loop := t.TempDir() + "/loop"
+		require.NoError(t, os.MkdirAll(loop+"/dir", 0o755))
+		require.NoError(t, os.Symlink("..", loop+"/dir/parent"))
+
+		err := CopyTree(loop, t.TempDir()+"/dst", TreeOptions{FollowSymlinks: true})
+		require.Truef(t, errors.Is(err, ErrSymlinkLoop), "actual error %q", err)
+		// без перехода по ссылкам петля копируется как ссылка
+		require.NoError(t, CopyTree(loop, t.TempDir()+"/dst", TreeOptions{}))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		dst := t.TempDir() + "/dst"
+		err := CopyTree(src, dst, TreeOptions{Options: Options{Offset: 10}})
+		require.Truef(t, errors.Is(err, ErrRecursiveRange), "actual error %q", err)
+		err = CopyTree(src, dst, TreeOptions{Options: Options{Limit: 10}})
+		require.Truef(t, errors.Is(err, ErrRecursiveRange), "actual error %q", err)
+		err = CopyTree(filepath.Join(src, "top.txt"), dst, TreeOptions{})
+		require.Truef(t, errors.Is(err, ErrUnsupportedFile), "actual error %q", err)
+		_, err = os.Stat(dst)
+		require.Truef(t, errors.Is(err, os.ErrNotExist), "actual error %q", err)
+	})
+}
+
+func TestParsePreserve(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect Preserve
+		error  error
+	}{
+		{input: "", expect: Preserve{}},
+		{input: "mode", expect: Preserve{Mode: true}},
+		{input: "mode, timestamps", expect: Preserve{Mode: true, Times: true}},
+		{input: "ownership,xattr", expect: Preserve{Owner: true, Xattrs: true}},
+		{input: "all", expect: Preserve{Mode: true, Owner: true, Times: true, Xattrs: true}},
+		{input: "mode,links", error: ErrUnknownPreserve},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParsePreserve(tc.input)
+			if tc.error != nil {
+				require.Truef(t, errors.Is(err, tc.error), "actual error %q", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, got)
+		})
+	}
+}